@@ -3,7 +3,12 @@
 
 package main
 
-import "sync"
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 const (
 	CommentStatusInitial   int = iota
@@ -11,12 +16,57 @@ const (
 	CommentStatusApproved
 	CommentStatusDenied
 	CommentStatusDisplayed
+	CommentStatusRetracted
+	CommentStatusDropped
 )
 
+// what a full queue does with a new comment
 const (
+	OverflowDropOldest = "drop-oldest"
+	OverflowReject     = "reject"
+)
+
+var (
+	// initial capacity of comment queues, set by configuration
 	QueueDefaultLength = 1000
+
+	QueueOverflowPolicies = []string{OverflowDropOldest, OverflowReject}
+
+	// retention of new activities and the loosest one an activity may have, set by configuration
+	DefaultRetention = RetentionPolicy{MaxComments: 100000, TTL: time.Hour, MaxQueueDepth: 10000, Overflow: OverflowDropOldest}
 )
 
+// limits on what an activity keeps in memory; zero values mean unlimited.
+// Comments are evicted once they reached a terminal status (denied, displayed, retracted
+// or dropped) and either the TTL has passed since or more than MaxComments are kept.
+// A full initial queue drops its oldest comment or rejects the push according to Overflow;
+// a full approved queue always drops its oldest comment, which is stale by then
+type RetentionPolicy struct {
+	MaxComments   int
+	TTL           time.Duration
+	MaxQueueDepth int
+	Overflow      string
+}
+
+// check for negative limits and unknown overflow policies
+func (p RetentionPolicy) Valid() bool {
+	return p.MaxComments >= 0 && p.TTL >= 0 && p.MaxQueueDepth >= 0 && containsString(QueueOverflowPolicies, p.Overflow)
+}
+
+// the policy with each limit no looser than the one of limits
+func (p RetentionPolicy) Clamp(limits RetentionPolicy) RetentionPolicy {
+	clamp := func(v int, limit int) int {
+		if limit > 0 && (v == 0 || v > limit) {
+			return limit
+		}
+		return v
+	}
+	p.MaxComments = clamp(p.MaxComments, limits.MaxComments)
+	p.MaxQueueDepth = clamp(p.MaxQueueDepth, limits.MaxQueueDepth)
+	p.TTL = time.Duration(clamp(int(p.TTL), int(limits.TTL)))
+	return p
+}
+
 // comment with labelled id and status
 type LabelComment struct {
 	Id         int
@@ -24,30 +74,241 @@ type LabelComment struct {
 	Type       string
 	Content    string
 	Attributes map[string]string
+	finishedAt time.Time
+	next       *LabelComment // link in the intake
+	repeats    int32         // duplicates collapsed into the comment, accessed atomically
+	Verdicts   []Verdict     // of moderation stages other than allow, set before the comment is added
+	Sender     string        // client id or else address of the sender if known, set before the comment is added
+}
+
+// whether moderation sent the comment to human review
+func (c *LabelComment) Held() bool {
+	for _, v := range c.Verdicts {
+		if v.Action == VerdictHold {
+			return true
+		}
+	}
+	return false
+}
+
+// whether a moderation stage had something against the comment, holding or rewriting it
+func (c *LabelComment) Flagged() bool {
+	for _, v := range c.Verdicts {
+		if v.Action != VerdictApprove {
+			return true
+		}
+	}
+	return false
+}
+
+// whether moderation approved the comment without holding it, so it skips review
+func (c *LabelComment) Passed() bool {
+	passed := false
+	for _, v := range c.Verdicts {
+		switch v.Action {
+		case VerdictHold:
+			return false
+		case VerdictApprove:
+			passed = true
+		}
+	}
+	return passed
 }
 
-// BasicActivity struct
+// times the comment was pushed, counting collapsed duplicates
+func (c *LabelComment) Multiplier() int {
+	return 1 + int(atomic.LoadInt32(&c.repeats))
+}
+
+// whether the comment is done with, though displayed ones may still be retracted
+func (c *LabelComment) terminal() bool {
+	return IsOneOf(c.Status, CommentStatusDenied, CommentStatusDisplayed, CommentStatusRetracted, CommentStatusDropped)
+}
+
+// BasicActivity struct; the zero value is ready to use.
+// Pushes go through a lock-free intake and a sharded index and only share pushMutex
+// with each other, the other operations take mutex and move pushed comments to the
+// initial queue first
 type BasicActivity struct {
 	mutex          sync.Mutex
-	CommentMap     map[int]*LabelComment
-	InitialQueue   []*LabelComment
-	ApprovedQueue  []*LabelComment
+	pushMutex      sync.RWMutex // shared by pushes, held alone by Reset
+	comments       commentIndex
+	intake         commentIntake
+	lastId         atomic.Int64
+	initialDepth   atomic.Int64 // pushed comments not yet reviewed or dropped
+	retention      atomic.Pointer[RetentionPolicy]
+	initial        commentRing
+	approved       commentRing
+	finished       commentRing
+	oldestFinished atomic.Int64 // finishedAt of the front of finished in unix nanoseconds, 0 if empty
+	RetractQueue   []*LabelComment
+	PendingCount   int
+	ApprovedCount  int
+	DeniedCount    int
+	DisplayedCount int
+	RetractedCount int
+	DroppedCount   int
+	EvictedCount   int
+	now            func() time.Time
+}
+
+// snapshot of queue depths and counters
+type ActivityStats struct {
+	InitialDepth   int
+	PendingDepth   int
+	ApprovedDepth  int
+	RetainedCount  int
 	TotalCount     int
 	ApprovedCount  int
 	DeniedCount    int
 	DisplayedCount int
+	RetractedCount int
+	DroppedCount   int
+	EvictedCount   int
+}
+
+func (act *BasicActivity) clock() time.Time {
+	if act.now == nil {
+		return time.Now()
+	}
+	return act.now()
+}
+
+// set the status of a comment, remembering when it first became terminal; must hold the mutex
+func (act *BasicActivity) finish(c *LabelComment, status int) {
+	c.Status = status
+	if c.finishedAt.IsZero() && c.terminal() {
+		c.finishedAt = act.clock()
+		act.finished.push(c)
+		if act.finished.Len() == 1 {
+			act.oldestFinished.Store(c.finishedAt.UnixNano())
+		}
+	}
+}
+
+// keep oldestFinished in step after removing finished comments; must hold the mutex
+func (act *BasicActivity) markOldest() {
+	if act.finished.Len() == 0 {
+		act.oldestFinished.Store(0)
+		return
+	}
+	act.oldestFinished.Store(act.finished.front().finishedAt.UnixNano())
+}
+
+// whether finished comments are due for eviction, without holding the mutex
+func (act *BasicActivity) evictionDue(p RetentionPolicy) bool {
+	oldest := act.oldestFinished.Load()
+	if oldest == 0 {
+		return false
+	}
+	return p.MaxComments > 0 && act.comments.Len() > p.MaxComments ||
+		p.TTL > 0 && act.clock().UnixNano()-oldest >= int64(p.TTL)
+}
+
+// drop the oldest comment of a queue; must hold the mutex
+func (act *BasicActivity) dropOldest(q *commentRing) {
+	if q == &act.initial {
+		act.initialDepth.Add(-1)
+	}
+	act.finish(q.popFront(), CommentStatusDropped)
+	act.DroppedCount++
+}
+
+// move pushed comments to the initial queue, then apply the retention policy; must hold the mutex
+func (act *BasicActivity) collect() {
+	act.intake.take(act.initial.push)
+	p := act.Retention()
+	for p.MaxQueueDepth > 0 && act.initial.Len() > p.MaxQueueDepth {
+		act.dropOldest(&act.initial)
+	}
+	for p.MaxQueueDepth > 0 && act.approved.Len() > p.MaxQueueDepth {
+		act.dropOldest(&act.approved)
+	}
+	act.evict(p)
+}
+
+// evict terminal comments past the TTL or beyond MaxComments, oldest first; must hold the mutex
+func (act *BasicActivity) evict(p RetentionPolicy) {
+	defer act.markOldest()
+	now := act.clock()
+	for act.finished.Len() > 0 {
+		c := act.finished.front()
+		expired := p.TTL > 0 && now.Sub(c.finishedAt) >= p.TTL
+		over := p.MaxComments > 0 && act.comments.Len() > p.MaxComments
+		if !expired && !over {
+			return
+		}
+		act.finished.popFront()
+		act.comments.del(c.Id)
+		act.EvictedCount++
+	}
+}
+
+// current retention policy
+func (act *BasicActivity) Retention() RetentionPolicy {
+	if p := act.retention.Load(); p != nil {
+		return *p
+	}
+	return RetentionPolicy{}
+}
+
+// replace the retention policy, applying it to what is kept already
+func (act *BasicActivity) SetRetention(p RetentionPolicy) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.retention.Store(&p)
+	act.collect()
+}
+
+// add a comment, initialized with an unique id, Initial status and the verdicts of moderation;
+// nil when the initial queue is full and the retention policy rejects new comments
+func (act *BasicActivity) Add(c Comment, verdicts ...Verdict) *LabelComment {
+	return act.AddFrom(c, "", verdicts...)
+}
+
+// add a comment of a sender, see Add
+func (act *BasicActivity) AddFrom(c Comment, sender string, verdicts ...Verdict) *LabelComment {
+	act.pushMutex.RLock()
+	defer act.pushMutex.RUnlock()
+
+	p := act.Retention()
+	depth := act.initialDepth.Add(1)
+	overflow := p.MaxQueueDepth > 0 && depth > int64(p.MaxQueueDepth)
+	if overflow && p.Overflow == OverflowReject {
+		act.initialDepth.Add(-1)
+		return nil
+	}
+	if overflow || act.evictionDue(p) {
+		// drop the oldest and evict now rather than at the next poll, unless someone else is at it
+		defer func() {
+			if act.mutex.TryLock() {
+				act.collect()
+				act.mutex.Unlock()
+			}
+		}()
+	}
+
+	id := int(act.lastId.Add(1))
+	lc := &LabelComment{Id: id, Type: c.Type(), Content: c.Content(), Attributes: c.Attributes(), Status: CommentStatusInitial, Verdicts: verdicts, Sender: sender}
+	act.comments.put(lc)
+	act.intake.push(lc)
+	return lc
 }
 
-// add a comment, initialized with an unique id and Initial status
-func (act *BasicActivity) Add(c Comment) *LabelComment {
+// add a comment already denied, for comments refused before review; it is kept like
+// other denied comments but never queued
+func (act *BasicActivity) AddDenied(c Comment, sender string, verdicts ...Verdict) *LabelComment {
+	act.pushMutex.RLock()
+	defer act.pushMutex.RUnlock()
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
-	act.TotalCount++
-	id := act.TotalCount
-	lc := &LabelComment{Id: id, Type: c.Type(), Content: c.Content(), Attributes: c.Attributes(), Status: CommentStatusInitial}
-	act.CommentMap[id] = lc
-	act.InitialQueue = append(act.InitialQueue, lc)
+	id := int(act.lastId.Add(1))
+	lc := &LabelComment{Id: id, Type: c.Type(), Content: c.Content(), Attributes: c.Attributes(), Verdicts: verdicts, Sender: sender}
+	act.comments.put(lc)
+	act.finish(lc, CommentStatusDenied)
+	act.DeniedCount++
 	return lc
 }
 
@@ -56,25 +317,93 @@ func (act *BasicActivity) Review() (r []*LabelComment) {
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
-	r = act.InitialQueue
-	act.InitialQueue = make([]*LabelComment, 0, QueueDefaultLength)
+	act.collect()
+	r = act.initial.drain()
+	act.initialDepth.Add(-int64(len(r)))
 	for _, d := range r {
 		d.Status = CommentStatusPending
 	}
+	act.PendingCount += len(r)
+	return
+}
+
+// count a duplicate on a comment while it waits for review or display,
+// false once it no longer does
+func (act *BasicActivity) Collapse(c *LabelComment) bool {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	if !IsOneOf(c.Status, CommentStatusInitial, CommentStatusPending, CommentStatusApproved) {
+		return false
+	}
+	atomic.AddInt32(&c.repeats, 1)
+	return true
+}
+
+// get comments with Initial status matching f as Review does, the others stay for reviewers
+func (act *BasicActivity) ReviewWhere(f func(c *LabelComment) bool) []*LabelComment {
+	return act.ReviewSelect(f, nil, 0)
+}
+
+// get comments with Initial status matching f, nil for all, sorted by less if not nil and
+// at most limit of them if positive, as Review does; the others stay for reviewers in order
+func (act *BasicActivity) ReviewSelect(f func(c *LabelComment) bool, less func(a, b *LabelComment) bool, limit int) (r []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.collect()
+	cs := act.initial.drain()
+	for _, c := range cs {
+		if f == nil || f(c) {
+			r = append(r, c)
+		}
+	}
+	if less != nil {
+		sort.SliceStable(r, func(i, j int) bool { return less(r[i], r[j]) })
+	}
+	if limit > 0 && len(r) > limit {
+		r = r[:limit]
+	}
+	if len(r) < len(cs) {
+		picked := make(map[*LabelComment]bool, len(r))
+		for _, c := range r {
+			picked[c] = true
+		}
+		for _, c := range cs {
+			if !picked[c] {
+				act.initial.push(c)
+			}
+		}
+	}
+	for _, c := range r {
+		c.Status = CommentStatusPending
+	}
+	act.initialDepth.Add(-int64(len(r)))
+	act.PendingCount += len(r)
 	return
 }
 
+// keep PendingCount in step before a comment changes status, must hold the mutex
+func (act *BasicActivity) leavePending(c *LabelComment) {
+	if c.Status == CommentStatusPending {
+		act.PendingCount--
+	}
+}
+
 // approve comments, that is, change their status to Approved
 func (act *BasicActivity) Approve(lcs []*LabelComment) {
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
-	act.ApprovedQueue = append(act.ApprovedQueue, lcs...)
+	act.approve(lcs, true)
+}
 
-	for _, c := range lcs {
-		c.Status = CommentStatusApproved
-	}
-	act.ApprovedCount += int(len(lcs))
+// approve comments without queueing them for displaying, for comments shown by other means
+func (act *BasicActivity) ApproveUnqueued(lcs []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.approve(lcs, false)
 }
 
 // deny comments, that is, change their status to Denied
@@ -82,8 +411,39 @@ func (act *BasicActivity) Deny(lcs []*LabelComment) {
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
+	act.deny(lcs)
+}
+
+// approve and deny comments at once, so that displays never see part of the verdicts;
+// approved comments are queued for displaying unless unqueued
+func (act *BasicActivity) Decide(approved []*LabelComment, denied []*LabelComment, unqueued bool) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.approve(approved, !unqueued)
+	act.deny(denied)
+}
+
+// must hold the mutex
+func (act *BasicActivity) approve(lcs []*LabelComment, queue bool) {
+	for _, c := range lcs {
+		act.leavePending(c)
+		c.Status = CommentStatusApproved
+		if queue {
+			act.approved.push(c)
+		}
+	}
+	act.ApprovedCount += len(lcs)
+	if queue {
+		act.collect()
+	}
+}
+
+// must hold the mutex
+func (act *BasicActivity) deny(lcs []*LabelComment) {
 	for _, c := range lcs {
-		c.Status = CommentStatusDenied
+		act.leavePending(c)
+		act.finish(c, CommentStatusDenied)
 	}
 	act.DeniedCount += len(lcs)
 }
@@ -93,40 +453,142 @@ func (act *BasicActivity) Display() (r []*LabelComment) {
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
-	r = act.ApprovedQueue
-	act.ApprovedQueue = make([]*LabelComment, 0, QueueDefaultLength)
+	act.collect()
+	r = act.approved.drain()
 	for _, c := range r {
-		c.Status = CommentStatusDisplayed
+		act.finish(c, CommentStatusDisplayed)
 	}
 	act.DisplayedCount += len(r)
 	return
 }
 
-// get comments by their ids
-func (act *BasicActivity) Fetch(ids []int) (r []*LabelComment) {
+// retract approved or displayed comments, that is, change their status to Retracted;
+// approved ones are removed from the approved queue, displayed ones are queued for displays to remove
+func (act *BasicActivity) Retract(lcs []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	for _, c := range lcs {
+		switch c.Status {
+		case CommentStatusApproved:
+			act.approved.remove(c)
+		case CommentStatusDisplayed:
+			act.RetractQueue = append(act.RetractQueue, c)
+		default:
+			continue
+		}
+		act.finish(c, CommentStatusRetracted)
+		act.RetractedCount++
+	}
+}
+
+// get displayed comments which have been retracted since last call
+func (act *BasicActivity) Retractions() (r []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	r = act.RetractQueue
+	act.RetractQueue = nil
+	return
+}
+
+// put comments taken for reviewing back in front of the queue, those still pending,
+// for reviewers that never got them
+func (act *BasicActivity) Unreview(lcs []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	var back []*LabelComment
+	for _, c := range lcs {
+		if c.Status == CommentStatusPending {
+			c.Status = CommentStatusInitial
+			back = append(back, c)
+		}
+	}
+	act.collect()
+	act.initial.prepend(back)
+	act.initialDepth.Add(int64(len(back)))
+	act.PendingCount -= len(back)
+}
+
+// put comments taken for displaying back in front of the queue, those not retracted since,
+// and the retractions taken along, for displays that never got them
+func (act *BasicActivity) Undisplay(lcs []*LabelComment, retracted []*LabelComment) {
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
+	var back []*LabelComment
+	for _, c := range lcs {
+		if c.Status == CommentStatusDisplayed {
+			act.finished.remove(c)
+			act.markOldest()
+			c.finishedAt = time.Time{}
+			c.Status = CommentStatusApproved
+			back = append(back, c)
+		}
+	}
+	act.collect()
+	act.approved.prepend(back)
+	act.DisplayedCount -= len(back)
+	if len(retracted) > 0 {
+		act.RetractQueue = append(append([]*LabelComment(nil), retracted...), act.RetractQueue...)
+	}
+}
+
+// get comments by their ids
+func (act *BasicActivity) Fetch(ids []int) (r []*LabelComment) {
 	r = make([]*LabelComment, 0, len(ids))
 	for _, id := range ids {
-		if d, ok := act.CommentMap[id]; ok {
+		if d, ok := act.comments.get(id); ok {
 			r = append(r, d)
 		}
 	}
 	return
 }
 
+// get queue depths and counters; read only, comments pushed since the last poll count in
+// InitialDepth but the retention policy is applied to them by pushes, reviews and displays
+func (act *BasicActivity) Stats() ActivityStats {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	return ActivityStats{
+		InitialDepth:   int(act.initialDepth.Load()),
+		PendingDepth:   act.PendingCount,
+		ApprovedDepth:  act.approved.Len(),
+		RetainedCount:  act.comments.Len(),
+		TotalCount:     int(act.lastId.Load()),
+		ApprovedCount:  act.ApprovedCount,
+		DeniedCount:    act.DeniedCount,
+		DisplayedCount: act.DisplayedCount,
+		RetractedCount: act.RetractedCount,
+		DroppedCount:   act.DroppedCount,
+		EvictedCount:   act.EvictedCount,
+	}
+}
+
 // reset the activity
 func (act *BasicActivity) Reset() {
+	act.pushMutex.Lock()
+	defer act.pushMutex.Unlock()
 	act.mutex.Lock()
 	defer act.mutex.Unlock()
 
-	act.TotalCount = 0
+	act.PendingCount = 0
 	act.ApprovedCount = 0
 	act.DeniedCount = 0
 	act.DisplayedCount = 0
+	act.RetractedCount = 0
+	act.DroppedCount = 0
+	act.EvictedCount = 0
 
-	act.CommentMap = make(map[int]*LabelComment)
-	act.InitialQueue = make([]*LabelComment, 0, QueueDefaultLength)
-	act.ApprovedQueue = make([]*LabelComment, 0, QueueDefaultLength)
+	act.intake.take(func(*LabelComment) {})
+	act.comments.reset()
+	act.lastId.Store(0)
+	act.initialDepth.Store(0)
+	act.initial = commentRing{}
+	act.approved = commentRing{}
+	act.finished = commentRing{}
+	act.oldestFinished.Store(0)
+	act.RetractQueue = nil
 }
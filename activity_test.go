@@ -2,15 +2,12 @@ package main
 
 import (
 	"testing"
+	"time"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestBasicActivity(t *testing.T) {
-	act := &BasicActivity{
-		CommentMap:    make(map[int]*LabelComment),
-		InitialQueue:  make([]*LabelComment, 0, QueueDefaultLength),
-		ApprovedQueue: make([]*LabelComment, 0, QueueDefaultLength),
-	}
+	act := &BasicActivity{}
 
 	bc1 := NewTextComment("content", "red")
 	bc2 := NewTextComment("content", "green")
@@ -20,19 +17,19 @@ func TestBasicActivity(t *testing.T) {
 	assert.Equal(t, bc1.Attributes(), c1.Attributes)
 	assert.Equal(t, "text", c1.Type)
 	assert.Equal(t, CommentStatusInitial, c1.Status)
-	assert.Equal(t, act.TotalCount, c1.Id)
-	assert.Equal(t, 1, act.TotalCount)
+	assert.Equal(t, act.Stats().TotalCount, c1.Id)
+	assert.Equal(t, 1, act.Stats().TotalCount)
 	assert.Equal(t, 0, act.ApprovedCount)
 	assert.Equal(t, 0, act.DeniedCount)
 	assert.Equal(t, 0, act.DisplayedCount)
 
 	c2 := act.Add(bc2)
-	assert.Equal(t, act.TotalCount, c2.Id)
-	assert.Equal(t, 2, act.TotalCount)
+	assert.Equal(t, act.Stats().TotalCount, c2.Id)
+	assert.Equal(t, 2, act.Stats().TotalCount)
 
 	rcs := act.Review()
 	assert.Equal(t, 2, len(rcs))
-	assert.Equal(t, 2, act.TotalCount)
+	assert.Equal(t, 2, act.Stats().TotalCount)
 	assert.Equal(t, 0, act.ApprovedCount)
 	assert.Equal(t, 0, act.DeniedCount)
 	assert.Equal(t, 0, act.DisplayedCount)
@@ -42,7 +39,7 @@ func TestBasicActivity(t *testing.T) {
 	}
 
 	act.Approve(rcs)
-	assert.Equal(t, 2, act.TotalCount)
+	assert.Equal(t, 2, act.Stats().TotalCount)
 	assert.Equal(t, 2, act.ApprovedCount)
 	assert.Equal(t, 0, act.DeniedCount)
 	assert.Equal(t, 0, act.DisplayedCount)
@@ -51,14 +48,14 @@ func TestBasicActivity(t *testing.T) {
 	c4 := act.Add(NewTextComment("content2", "red"))
 	act.Deny([]*LabelComment{c3, c4})
 
-	assert.Equal(t, 4, act.TotalCount)
+	assert.Equal(t, 4, act.Stats().TotalCount)
 	assert.Equal(t, 2, act.ApprovedCount)
 	assert.Equal(t, 2, act.DeniedCount)
 	assert.Equal(t, 0, act.DisplayedCount)
 
 	dcs := act.Display()
 	assert.Equal(t, 2, len(dcs))
-	assert.Equal(t, 4, act.TotalCount)
+	assert.Equal(t, 4, act.Stats().TotalCount)
 	assert.Equal(t, 2, act.ApprovedCount)
 	assert.Equal(t, 2, act.DeniedCount)
 	assert.Equal(t, 2, act.DisplayedCount)
@@ -68,10 +65,119 @@ func TestBasicActivity(t *testing.T) {
 	}
 
 	act.Reset()
-	assert.Equal(t, 0, act.TotalCount)
+	assert.Equal(t, 0, act.Stats().TotalCount)
 	assert.Equal(t, 0, act.ApprovedCount)
 	assert.Equal(t, 0, act.DeniedCount)
 	assert.Equal(t, 0, act.DisplayedCount)
-	assert.Equal(t, 0, len(act.InitialQueue))
-	assert.Equal(t, 0, len(act.ApprovedQueue))
+	assert.Equal(t, 0, act.Stats().InitialDepth)
+	assert.Equal(t, 0, act.Stats().ApprovedDepth)
+}
+
+func TestBasicActivity_Retract(t *testing.T) {
+	act := &BasicActivity{}
+
+	c1 := act.Add(NewTextComment("content1", "red"))
+	c2 := act.Add(NewTextComment("content2", "red"))
+	c3 := act.Add(NewTextComment("content3", "red"))
+	act.Approve(act.Review())
+
+	act.Retract([]*LabelComment{c1})
+	assert.Equal(t, CommentStatusRetracted, c1.Status)
+	assert.Equal(t, 1, act.RetractedCount)
+	assert.Equal(t, 0, len(act.Retractions()))
+
+	dcs := act.Display()
+	assert.Equal(t, []*LabelComment{c2, c3}, dcs)
+
+	act.Retract([]*LabelComment{c2})
+	assert.Equal(t, CommentStatusRetracted, c2.Status)
+	assert.Equal(t, 2, act.RetractedCount)
+	assert.Equal(t, []*LabelComment{c2}, act.Retractions())
+	assert.Equal(t, 0, len(act.Retractions()))
+
+	// retracting twice or retracting a comment not yet approved has no effect
+	c4 := act.Add(NewTextComment("content4", "red"))
+	act.Retract([]*LabelComment{c2, c4})
+	assert.Equal(t, CommentStatusInitial, c4.Status)
+	assert.Equal(t, 2, act.RetractedCount)
+
+	act.Reset()
+	assert.Equal(t, 0, act.RetractedCount)
+	assert.Equal(t, 0, len(act.RetractQueue))
+}
+
+func TestBasicActivity_QueueOverflow(t *testing.T) {
+	act := &BasicActivity{}
+	act.SetRetention(RetentionPolicy{MaxQueueDepth: 2, Overflow: OverflowDropOldest})
+
+	c1 := act.Add(NewTextComment("content1", "red"))
+	c2 := act.Add(NewTextComment("content2", "red"))
+	c3 := act.Add(NewTextComment("content3", "red"))
+	assert.Equal(t, CommentStatusDropped, c1.Status)
+	assert.Equal(t, []*LabelComment{c2, c3}, act.initial.items())
+	assert.Equal(t, 1, act.Stats().DroppedCount)
+
+	// the approved queue drops its oldest comments whatever the policy
+	act.SetRetention(RetentionPolicy{MaxQueueDepth: 2, Overflow: OverflowReject})
+	act.Approve(act.Review())
+	c4 := act.Add(NewTextComment("content4", "red"))
+	act.Approve(act.Review())
+	assert.Equal(t, CommentStatusDropped, c2.Status)
+	assert.Equal(t, []*LabelComment{c3, c4}, act.approved.items())
+
+	act.Add(NewTextComment("content5", "red"))
+	act.Add(NewTextComment("content6", "red"))
+	assert.Nil(t, act.Add(NewTextComment("content7", "red")))
+	assert.Equal(t, 6, act.Stats().TotalCount)
+	assert.Equal(t, 2, act.Stats().DroppedCount)
+
+	// tightening the limit applies to queued comments
+	act.SetRetention(RetentionPolicy{MaxQueueDepth: 1, Overflow: OverflowReject})
+	assert.Equal(t, []*LabelComment{c4}, act.approved.items())
+	assert.Equal(t, 1, act.Stats().InitialDepth)
+	assert.Equal(t, 4, act.Stats().DroppedCount)
+}
+
+func TestBasicActivity_Eviction(t *testing.T) {
+	now := time.Now()
+	act := &BasicActivity{now: func() time.Time { return now }}
+	act.SetRetention(RetentionPolicy{TTL: time.Minute, Overflow: OverflowDropOldest})
+
+	c1 := act.Add(NewTextComment("content1", "red"))
+	c2 := act.Add(NewTextComment("content2", "red"))
+	c3 := act.Add(NewTextComment("content3", "red"))
+	act.Review()
+	act.Deny([]*LabelComment{c1})
+	act.Approve([]*LabelComment{c2})
+
+	// pending and approved comments are never evicted; stats only look, pushes evict
+	now = now.Add(time.Minute)
+	assert.Equal(t, 0, act.Stats().EvictedCount)
+	act.Add(NewTextComment("content4", "red"))
+	assert.Equal(t, 3, act.Stats().RetainedCount)
+	assert.Equal(t, 1, act.Stats().EvictedCount)
+	assert.Equal(t, 0, len(act.Fetch([]int{c1.Id})))
+
+	// displayed comments can still be retracted until evicted
+	act.Display()
+	act.Retract(act.Fetch([]int{c2.Id}))
+	assert.Equal(t, CommentStatusRetracted, c2.Status)
+
+	// beyond the maximum the oldest finished comments go first
+	act.SetRetention(RetentionPolicy{MaxComments: 2, Overflow: OverflowDropOldest})
+	assert.Equal(t, 2, act.Stats().RetainedCount)
+	assert.Equal(t, []*LabelComment{c3}, act.Fetch([]int{c2.Id, c3.Id}))
+	assert.Equal(t, 2, act.Stats().EvictedCount)
+
+	act.Reset()
+	assert.Equal(t, 0, act.Stats().EvictedCount)
+}
+
+func TestRetentionPolicy_Clamp(t *testing.T) {
+	limits := RetentionPolicy{MaxComments: 100, TTL: time.Hour, Overflow: OverflowDropOldest}
+	p := RetentionPolicy{MaxComments: 0, TTL: time.Minute, MaxQueueDepth: 10, Overflow: OverflowReject}.Clamp(limits)
+	assert.Equal(t, RetentionPolicy{MaxComments: 100, TTL: time.Minute, MaxQueueDepth: 10, Overflow: OverflowReject}, p)
+	assert.True(t, p.Valid())
+	assert.False(t, RetentionPolicy{MaxComments: -1, Overflow: OverflowReject}.Valid())
+	assert.False(t, RetentionPolicy{Overflow: "ignore"}.Valid())
 }
@@ -0,0 +1,92 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	AuditLogMaxLength = 10000
+)
+
+// one admin or reviewer action
+type AuditEntry struct {
+	Seq        int
+	Time       time.Time
+	Role       string
+	ActivityId int
+	Action     string
+	Ids        []int
+	Detail     string
+}
+
+// filter of audit entries; zero fields match everything
+type AuditFilter struct {
+	ActivityId int
+	Role       string
+	Action     string
+	AfterSeq   int
+	Limit      int
+}
+
+func (f *AuditFilter) match(en *AuditEntry) bool {
+	return (f.ActivityId == 0 || f.ActivityId == en.ActivityId) &&
+		(f.Role == "" || f.Role == en.Role) &&
+		(f.Action == "" || f.Action == en.Action) &&
+		en.Seq > f.AfterSeq
+}
+
+func NewAuditLog() *AuditLog {
+	return &AuditLog{
+		Entries: make([]*AuditEntry, 0, AuditLogMaxLength),
+		now:     time.Now,
+	}
+}
+
+// AuditLog keeps the latest AuditLogMaxLength actions in memory
+type AuditLog struct {
+	mutex    sync.Mutex
+	Entries  []*AuditEntry
+	SeqCount int
+	now      func() time.Time
+}
+
+// append an entry, dropping the oldest one when full
+func (l *AuditLog) Record(role string, activityId int, action string, ids []int, detail string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.SeqCount++
+	if len(l.Entries) == AuditLogMaxLength {
+		l.Entries = append(l.Entries[:0], l.Entries[1:]...)
+	}
+	l.Entries = append(l.Entries, &AuditEntry{
+		Seq:        l.SeqCount,
+		Time:       l.now(),
+		Role:       role,
+		ActivityId: activityId,
+		Action:     action,
+		Ids:        ids,
+		Detail:     detail,
+	})
+}
+
+// entries matching the filter in order, at most Limit of them if set
+func (l *AuditLog) Query(f *AuditFilter) []*AuditEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	r := make([]*AuditEntry, 0)
+	for _, en := range l.Entries {
+		if f.Limit > 0 && len(r) == f.Limit {
+			break
+		}
+		if f.match(en) {
+			r = append(r, en)
+		}
+	}
+	return r
+}
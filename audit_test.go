@@ -0,0 +1,28 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	l := NewAuditLog()
+	l.Record("admin", 1, "NewActivity", nil, "First")
+	l.Record("admin", 2, "NewActivity", nil, "Second")
+	l.Record("review", 1, "Approve", []int{1, 2}, "")
+	l.Record("review", 1, "Deny", []int{3}, "")
+
+	assert.Equal(t, 4, len(l.Query(&AuditFilter{})))
+	assert.Equal(t, 3, len(l.Query(&AuditFilter{ActivityId: 1})))
+	assert.Equal(t, 2, len(l.Query(&AuditFilter{Role: "review"})))
+	assert.Equal(t, []int{1, 2}, l.Query(&AuditFilter{Action: "Approve"})[0].Ids)
+	assert.Equal(t, 2, len(l.Query(&AuditFilter{AfterSeq: 2})))
+	assert.Equal(t, 1, l.Query(&AuditFilter{Limit: 1})[0].Seq)
+
+	for i := 0; i < AuditLogMaxLength; i++ {
+		l.Record("admin", 1, "Reset", nil, "")
+	}
+	ens := l.Query(&AuditFilter{})
+	assert.Equal(t, AuditLogMaxLength, len(ens))
+	assert.Equal(t, 5, ens[0].Seq)
+}
@@ -0,0 +1,119 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// largest JSON-RPC request body read, batch or not
+var BatchMaxBytes int64 = 1 << 20
+
+// response writer buffering one call of a batch
+type batchWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *batchWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// serve JSON-RPC batch arrays: each call goes to h as a request of its own, in order,
+// and the responses are returned as an array; anything else goes to h untouched.
+// A call answered with something other than JSON gets an error response with its id,
+// notifications, calls without id, get none. Bodies over BatchMaxBytes are refused
+func BatchRPC(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, BatchMaxBytes))
+		r.Body.Close()
+		if _, ok := err.(*http.MaxBytesError); ok {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var calls []json.RawMessage
+		if len(bytes.TrimSpace(body)) == 0 || bytes.TrimSpace(body)[0] != '[' || json.Unmarshal(body, &calls) != nil {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			h.ServeHTTP(w, r)
+			return
+		}
+		if len(calls) == 0 {
+			http.Error(w, "empty batch", http.StatusBadRequest)
+			return
+		}
+		if len(calls) > BatchMaxLength {
+			http.Error(w, BatchTooLongError.Message, BatchTooLongError.Code)
+			return
+		}
+
+		responses := make([]json.RawMessage, 0, len(calls))
+		for _, call := range calls {
+			sub := r.Clone(r.Context())
+			sub.Body = ioutil.NopCloser(bytes.NewReader(call))
+			sub.ContentLength = int64(len(call))
+			bw := &batchWriter{header: make(http.Header), status: http.StatusOK}
+			h.ServeHTTP(bw, sub)
+			if !isNotification(call) {
+				responses = append(responses, batchResponse(call, bw))
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(responses)
+	})
+}
+
+// whether a call has no id, or a null one, and expects no response
+func isNotification(call []byte) bool {
+	var req struct {
+		Id json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(call, &req)
+	return len(req.Id) == 0 || string(req.Id) == "null"
+}
+
+// the JSON response of a call, or an error response when the handler answered otherwise
+func batchResponse(call []byte, bw *batchWriter) json.RawMessage {
+	res := bytes.TrimSpace(bw.body.Bytes())
+	if json.Valid(res) && len(res) > 0 {
+		return res
+	}
+	var req struct {
+		Id interface{} `json:"id"`
+	}
+	json.Unmarshal(call, &req)
+	code := bw.status
+	if code == http.StatusOK {
+		code = http.StatusInternalServerError
+	}
+	b, _ := json.Marshal(map[string]interface{}{
+		"result": nil,
+		"error":  map[string]interface{}{"code": code, "message": string(res)},
+		"id":     req.Id,
+	})
+	return b
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchRPC(t *testing.T) {
+	calls := 0
+	h := BatchRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		req := peekRequest(r)
+		if req.Method == "Evil.Method" {
+			http.Error(w, "rpc: can't find service", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": req.Token, "error": nil, "id": req.Id})
+	}))
+	serve := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		return w
+	}
+
+	// single calls pass untouched
+	w := serve(`{"method":"DanmakuService.Review","params":[{"Token":"a","Id":1}],"id":1}`)
+	assert.Equal(t, 1, calls)
+	assert.JSONEq(t, `{"result":"a","error":null,"id":1}`, w.Body.String())
+
+	// batch calls are answered in order
+	w = serve(`[{"method":"DanmakuService.Review","params":[{"Token":"a","Id":1}],"id":1},
+		{"method":"Evil.Method","id":"x"},
+		{"method":"DanmakuService.Review","params":[{"Token":"b","Id":3}],"id":3}]`)
+	assert.Equal(t, 4, calls)
+	assert.JSONEq(t, `[{"result":"a","error":null,"id":1},
+		{"result":null,"error":{"code":400,"message":"rpc: can't find service"},"id":"x"},
+		{"result":"b","error":null,"id":3}]`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, serve(`[]`).Code)
+	long := "[" + strings.Repeat(`{"id":1},`, BatchMaxLength) + `{"id":1}]`
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve(long).Code)
+	assert.Equal(t, 4, calls)
+
+	// notifications are served but not answered
+	w = serve(`[{"method":"DanmakuService.Review","params":[{"Token":"a"}]},
+		{"method":"DanmakuService.Review","params":[{"Token":"b"}],"id":null},
+		{"method":"DanmakuService.Review","params":[{"Token":"c"}],"id":0}]`)
+	assert.Equal(t, 7, calls)
+	assert.JSONEq(t, `[{"result":"c","error":null,"id":0}]`, w.Body.String())
+	w = serve(`[{"method":"DanmakuService.Review","params":[{"Token":"a"}]}]`)
+	assert.Equal(t, 8, calls)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestBatchRPC_MaxBytes(t *testing.T) {
+	defer func(n int64) { BatchMaxBytes = n }(BatchMaxBytes)
+	BatchMaxBytes = 64
+
+	calls := 0
+	h := BatchRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }))
+	serve := func(body string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(`{"method":"DanmakuService.Review","id":1}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve(`{"method":"DanmakuService.Review","params":[{"Token":"`+strings.Repeat("a", 64)+`"}],"id":1}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, serve(`[`+strings.Repeat(`{"id":1},`, 10)+`{"id":1}]`))
+	assert.Equal(t, 1, calls)
+
+	// cross-origin requests are bounded as well
+	cors := &CORSConfig{Public: CORSPolicy{Origins: []string{"*"}, Methods: []string{"POST"}}}
+	h = NewCORSHandler(NewEngine(), cors, h)
+	r := httptest.NewRequest("POST", "http://danmaku.example/", strings.NewReader(`[`+strings.Repeat(`{"id":1},`, 10)+`{"id":1}]`))
+	r.Header.Set("Origin", "https://fan.example")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, 1, calls)
+}
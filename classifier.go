@@ -0,0 +1,225 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	ClassifierDefaultTimeout  = time.Second
+	ClassifierDefaultRetries  = 1
+	ClassifierDefaultFailures = 5
+	ClassifierDefaultCooldown = 30 * time.Second
+	// calls in flight at once, pushes beyond wait for review instead
+	ClassifierDefaultConcurrency = 16
+	// wait before the first retry, doubled for each next one
+	classifierBackoff = 50 * time.Millisecond
+)
+
+// body posted to a classifier
+type classifierRequest struct {
+	Activity int          `json:"activity"`
+	Comment  *FlatComment `json:"comment"`
+	Sender   string       `json:"sender,omitempty"`
+}
+
+// answer of a classifier; the higher the score, the worse the comment
+type classifierReply struct {
+	Score  *float64 `json:"score"`
+	Reason string   `json:"reason"`
+}
+
+// scores comments with an external HTTP service: each comment is posted as JSON and the
+// service answers {"score": 0.93, "reason": "..."}. Comments scoring at most Approve are approved
+// without review, at least Deny are denied, the others wait for human review, as do all of them
+// while the service fails. After Failures calls failing in a row the service is left alone for
+// Cooldown, then a single call tries it again. At most Concurrency calls are in flight, the
+// comments pushed meanwhile wait for human review, so that a slow service does not hold up
+// every push: a push waits at most Timeout for each of the 1+Retries calls plus the backoff.
+// Options: url, approve and deny, scores between 0 and 1, either may be left out;
+// timeout, retries, failures, cooldown and concurrency
+type ClassifierModerator struct {
+	URL         string
+	Approve     float64 // negative for never
+	Deny        float64 // above 1 for never
+	Timeout     time.Duration
+	Retries     int
+	Failures    int
+	Cooldown    time.Duration
+	Concurrency int
+
+	slots     chan struct{} // one per call in flight
+	client    *http.Client
+	mutex     sync.Mutex
+	failed    int       // calls failed in a row
+	openUntil time.Time // no calls before
+	probing   bool      // a call is trying the service after the cooldown
+	now       func() time.Time
+}
+
+func NewClassifierModerator(options map[string]string) (Moderator, error) {
+	m := &ClassifierModerator{
+		URL:         options["url"],
+		Approve:     -1,
+		Deny:        2,
+		Timeout:     ClassifierDefaultTimeout,
+		Retries:     ClassifierDefaultRetries,
+		Failures:    ClassifierDefaultFailures,
+		Cooldown:    ClassifierDefaultCooldown,
+		Concurrency: ClassifierDefaultConcurrency,
+		now:         time.Now,
+	}
+	if u, err := url.Parse(m.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an http or https URL")
+	}
+	var err error
+	if s, ok := options["approve"]; ok {
+		if m.Approve, err = strconv.ParseFloat(s, 64); err != nil || m.Approve < 0 || m.Approve > 1 {
+			return nil, fmt.Errorf("approve must be a score between 0 and 1")
+		}
+	}
+	if s, ok := options["deny"]; ok {
+		if m.Deny, err = strconv.ParseFloat(s, 64); err != nil || m.Deny < 0 || m.Deny > 1 {
+			return nil, fmt.Errorf("deny must be a score between 0 and 1")
+		}
+	}
+	if m.Approve >= m.Deny {
+		return nil, fmt.Errorf("approve must be below deny")
+	}
+	if s, ok := options["timeout"]; ok {
+		if m.Timeout, err = time.ParseDuration(s); err != nil || m.Timeout <= 0 {
+			return nil, fmt.Errorf("timeout must be a positive duration")
+		}
+	}
+	if s, ok := options["retries"]; ok {
+		if m.Retries, err = strconv.Atoi(s); err != nil || m.Retries < 0 {
+			return nil, fmt.Errorf("retries must be a number")
+		}
+	}
+	if s, ok := options["failures"]; ok {
+		if m.Failures, err = strconv.Atoi(s); err != nil || m.Failures <= 0 {
+			return nil, fmt.Errorf("failures must be a positive number")
+		}
+	}
+	if s, ok := options["cooldown"]; ok {
+		if m.Cooldown, err = time.ParseDuration(s); err != nil || m.Cooldown <= 0 {
+			return nil, fmt.Errorf("cooldown must be a positive duration")
+		}
+	}
+	if s, ok := options["concurrency"]; ok {
+		if m.Concurrency, err = strconv.Atoi(s); err != nil || m.Concurrency <= 0 {
+			return nil, fmt.Errorf("concurrency must be a positive number")
+		}
+	}
+	m.slots = make(chan struct{}, m.Concurrency)
+	m.client = &http.Client{Timeout: m.Timeout}
+	return m, nil
+}
+
+func (m *ClassifierModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	default:
+		return Verdict{Action: VerdictHold, Reason: "classifier busy"}
+	}
+	if !m.acquire() {
+		return Verdict{Action: VerdictHold, Reason: "classifier unavailable"}
+	}
+	r, err := m.classify(act, c, s)
+	m.release(err == nil)
+	switch {
+	case err != nil:
+		return Verdict{Action: VerdictHold, Reason: "classifier unavailable: " + err.Error()}
+	case *r.Score >= m.Deny:
+		return Verdict{Action: VerdictDeny, Reason: classifierReason(r)}
+	case *r.Score <= m.Approve:
+		return Verdict{Action: VerdictApprove, Reason: classifierReason(r)}
+	}
+	return Verdict{Action: VerdictHold, Reason: classifierReason(r)}
+}
+
+func classifierReason(r *classifierReply) string {
+	reason := "score " + strconv.FormatFloat(*r.Score, 'g', 3, 64)
+	if r.Reason != "" {
+		reason += ": " + r.Reason
+	}
+	return reason
+}
+
+// whether the service may be called: the breaker is closed, or the cooldown is over
+// and nobody else is trying it
+func (m *ClassifierModerator) acquire() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.failed < m.Failures {
+		return true
+	}
+	if m.probing || m.now().Before(m.openUntil) {
+		return false
+	}
+	m.probing = true
+	return true
+}
+
+// count the outcome of a call, opening the breaker after too many failures
+func (m *ClassifierModerator) release(ok bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.probing = false
+	if ok {
+		m.failed = 0
+		return
+	}
+	m.failed++
+	if m.failed >= m.Failures {
+		m.openUntil = m.now().Add(m.Cooldown)
+	}
+}
+
+// post the comment, retrying failed calls except refusals
+func (m *ClassifierModerator) classify(act *Activity, c Comment, s *Sender) (*classifierReply, error) {
+	lc := &LabelComment{Type: c.Type(), Content: c.Content(), Attributes: c.Attributes()}
+	body, err := json.Marshal(&classifierRequest{Activity: act.Id, Comment: FlattenComment(lc), Sender: s.Addr})
+	if err != nil {
+		return nil, err
+	}
+	backoff := classifierBackoff
+	for i := 0; ; i++ {
+		r, retry, err := m.post(body)
+		if err == nil || !retry || i >= m.Retries {
+			return r, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// one call; retry tells whether another one may do better
+func (m *ClassifierModerator) post(body []byte) (r *classifierReply, retry bool, err error) {
+	res, err := m.client.Post(m.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, true, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests, fmt.Errorf("status %d", res.StatusCode)
+	}
+	r = &classifierReply{}
+	if err := json.NewDecoder(res.Body).Decode(r); err != nil || r.Score == nil {
+		return nil, false, fmt.Errorf("bad reply")
+	}
+	return r, false, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClassifierModerator(t *testing.T) {
+	for _, options := range []map[string]string{
+		{},
+		{"url": "ftp://localhost/"},
+		{"url": "http://localhost/", "approve": "1.5"},
+		{"url": "http://localhost/", "approve": "0.8", "deny": "0.2"},
+		{"url": "http://localhost/", "timeout": "0s"},
+		{"url": "http://localhost/", "failures": "0"},
+		{"url": "http://localhost/", "concurrency": "0"},
+	} {
+		_, err := NewClassifierModerator(options)
+		assert.NotNil(t, err, options)
+	}
+	m, err := NewClassifierModerator(map[string]string{"url": "http://localhost/", "approve": "0.2", "deny": "0.8", "retries": "0"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, m.(*ClassifierModerator).Retries)
+	assert.Equal(t, ClassifierDefaultTimeout, m.(*ClassifierModerator).Timeout)
+}
+
+func TestEngine_Classifier(t *testing.T) {
+	var calls, flaky int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var req classifierRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Comment.Content {
+		case "flaky":
+			// fails once, then answers
+			if atomic.AddInt32(&flaky, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"score": 0.1}`))
+		case "down":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "toxic":
+			w.Write([]byte(`{"score": 0.95, "reason": "insult"}`))
+		case "meh":
+			w.Write([]byte(`{"score": 0.5}`))
+		default:
+			w.Write([]byte(`{"score": 0.05}`))
+		}
+	}))
+	defer srv.Close()
+
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	push := func(text string) *LabelComment {
+		lc, err := e.Push(act.CommentToken, "text", map[string]string{"text": text, "color": "red"})
+		assert.Nil(t, err)
+		return lc
+	}
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{{Kind: "classifier", Options: map[string]string{
+		"url": srv.URL, "approve": "0.2", "deny": "0.8", "retries": "1", "failures": "2", "cooldown": "1m",
+	}}}))
+	cm := act.Settings().Moderation[0].Moderator.(*ClassifierModerator)
+	now := time.Unix(1000, 0)
+	cm.now = func() time.Time { return now }
+
+	// low scores skip review, high ones are denied, the others are left to reviewers
+	fine := push("hello")
+	assert.Equal(t, CommentStatusApproved, fine.Status)
+	toxic := push("toxic")
+	assert.Equal(t, CommentStatusDenied, toxic.Status)
+	assert.Equal(t, "score 0.95: insult", toxic.Verdicts[0].Reason)
+	meh := push("meh")
+	assert.Equal(t, CommentStatusInitial, meh.Status)
+	assert.Equal(t, CommentStatusApproved, push("flaky").Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&flaky))
+	rcs, _ := e.Review(act.ReviewToken)
+	assert.Equal(t, []*LabelComment{meh}, rcs)
+
+	// failing calls fall back to review, then the breaker stops calling for the cooldown
+	atomic.StoreInt32(&calls, 0)
+	down := push("down")
+	assert.True(t, down.Held())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	push("down")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	held := push("hello")
+	assert.Equal(t, "classifier unavailable", held.Verdicts[0].Reason)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// a call after the cooldown closes it again
+	now = now.Add(time.Minute)
+	assert.Equal(t, CommentStatusApproved, push("hello").Status)
+	assert.Equal(t, CommentStatusApproved, push("hello").Status)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestClassifierModerator_Busy(t *testing.T) {
+	entered, release := make(chan bool), make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- true
+		<-release
+		w.Write([]byte(`{"score": 0.5}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	m, err := NewClassifierModerator(map[string]string{"url": srv.URL, "concurrency": "1"})
+	assert.Nil(t, err)
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	c := NewTextComment("Hello", "red")
+	done := make(chan Verdict)
+	go func() { done <- m.Moderate(act, c, &Sender{}) }()
+	<-entered
+
+	// pushes beyond the calls in flight wait for review without calling
+	assert.Equal(t, Verdict{Action: VerdictHold, Reason: "classifier busy"}, m.Moderate(act, c, &Sender{}))
+	release <- true
+	assert.Equal(t, "score 0.5", (<-done).Reason)
+}
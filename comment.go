@@ -3,16 +3,45 @@
 
 package main
 
+import (
+	"math"
+	"strconv"
+	"strings"
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+)
+
+var (
+	UnknownTypeError  = &json.Error{Code: http.StatusBadRequest, Message: "unknown comment type",}
+	MissingTextError  = &json.Error{Code: http.StatusBadRequest, Message: "missing text",}
+	TextTooShortError = &json.Error{Code: http.StatusBadRequest, Message: "text too short",}
+	TextTooLongError  = &json.Error{Code: http.StatusBadRequest, Message: "text too long",}
+	BadColorError     = &json.Error{Code: http.StatusBadRequest, Message: "color not allowed",}
+	BadModeError      = &json.Error{Code: http.StatusBadRequest, Message: "mode not allowed",}
+	BadSizeError      = &json.Error{Code: http.StatusBadRequest, Message: "size not allowed",}
+	BadSpeedError     = &json.Error{Code: http.StatusBadRequest, Message: "speed out of range",}
+)
+
 /*
 Comment interface
 */
 
 func NewComment(tp string, attr map[string]string) (Comment, bool) {
+	c, err := NewStyledComment(tp, attr, DefaultTextStyle)
+	return c, err == nil
+}
+
+// build a comment, text comments follow the given style
+func NewStyledComment(tp string, attr map[string]string, style *TextStyle) (Comment, error) {
 	switch tp {
 	case "text":
-		return NewTextCommentFromMap(attr)
+		c, err := NewStyledTextCommentFromMap(attr, style)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
 	default:
-		return nil, false
+		return nil, UnknownTypeError
 	}
 }
 
@@ -26,19 +55,179 @@ type Comment interface {
 Text Comment
 */
 
+const (
+	TextModeScroll = "scroll"
+	TextModeTop    = "top"
+	TextModeBottom = "bottom"
+
+	TextSizeSmall  = "small"
+	TextSizeMedium = "medium"
+	TextSizeLarge  = "large"
+
+	TextSpeedMin = 0.25
+	TextSpeedMax = 4.0
+
+	TextDefaultMaxLength = 100
+)
+
+var (
+	TextModes = []string{TextModeScroll, TextModeTop, TextModeBottom}
+	TextSizes = []string{TextSizeSmall, TextSizeMedium, TextSizeLarge}
+
+	// basic named colors accepted besides #rgb and #rrggbb
+	TextNamedColors = []string{
+		"black", "silver", "gray", "white", "maroon", "red", "purple", "fuchsia",
+		"green", "lime", "olive", "yellow", "navy", "blue", "teal", "aqua",
+	}
+
+	DefaultTextStyle = &TextStyle{MinLength: 1, MaxLength: TextDefaultMaxLength}
+)
+
+// activity-level defaults and allowed values of text comments; empty means any valid value.
+// lengths are counted in user-perceived characters after normalization, zero means no limit
+type TextStyle struct {
+	DefaultColor string
+	DefaultMode  string
+	DefaultSize  string
+	Colors       []string
+	Modes        []string
+	Sizes        []string
+	MinLength    int
+	MaxLength    int
+}
+
+// check that the style itself is consistent
+func (st *TextStyle) Valid() bool {
+	if st.MinLength < 0 || st.MaxLength < 0 || st.MaxLength > 0 && st.MinLength > st.MaxLength {
+		return false
+	}
+	for _, c := range st.Colors {
+		if _, ok := NormalizeColor(c); !ok {
+			return false
+		}
+	}
+	for _, m := range st.Modes {
+		if !containsString(TextModes, m) {
+			return false
+		}
+	}
+	for _, z := range st.Sizes {
+		if !containsString(TextSizes, z) {
+			return false
+		}
+	}
+	if st.DefaultColor != "" && !st.allowColor(st.DefaultColor) {
+		return false
+	}
+	if st.DefaultMode != "" && !st.allowMode(st.DefaultMode) {
+		return false
+	}
+	if st.DefaultSize != "" && !st.allowSize(st.DefaultSize) {
+		return false
+	}
+	return true
+}
+
+func (st *TextStyle) allowColor(color string) bool {
+	color, ok := NormalizeColor(color)
+	if !ok {
+		return false
+	}
+	if len(st.Colors) == 0 {
+		return true
+	}
+	for _, c := range st.Colors {
+		if n, _ := NormalizeColor(c); n == color {
+			return true
+		}
+	}
+	return false
+}
+
+func (st *TextStyle) allowMode(mode string) bool {
+	return containsString(TextModes, mode) && (len(st.Modes) == 0 || containsString(st.Modes, mode))
+}
+
+func (st *TextStyle) allowSize(size string) bool {
+	return containsString(TextSizes, size) && (len(st.Sizes) == 0 || containsString(st.Sizes, size))
+}
+
+// normalize a color to lower case, accepting #rgb, #rrggbb and named colors
+func NormalizeColor(color string) (string, bool) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if containsString(TextNamedColors, color) {
+		return color, true
+	}
+	if len(color) != 4 && len(color) != 7 || color[0] != '#' {
+		return "", false
+	}
+	for _, r := range color[1:] {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return "", false
+		}
+	}
+	return color, true
+}
+
 func NewTextCommentFromMap(attr map[string]string) (*TextComment, bool) {
+	c, err := NewStyledTextCommentFromMap(attr, DefaultTextStyle)
+	return c, err == nil
+}
+
+// build a text comment from attributes, normalizing the text and applying defaults and allowed values of style
+func NewStyledTextCommentFromMap(attr map[string]string, style *TextStyle) (*TextComment, error) {
 	text, ok := attr["text"]
 	if !ok {
-		return nil, false
+		return nil, MissingTextError
+	}
+	if len(text) > TextMaxBytes {
+		return nil, TextTooLongError
+	}
+	text = NormalizeText(text)
+	n := TextLength(text)
+	if n == 0 || n < style.MinLength {
+		return nil, TextTooShortError
 	}
+	if style.MaxLength > 0 && n > style.MaxLength {
+		return nil, TextTooLongError
+	}
+
 	color, ok := attr["color"]
 	if !ok {
-		return nil, false
+		if style.DefaultColor == "" {
+			return nil, BadColorError
+		}
+		color = style.DefaultColor
+	}
+	if !style.allowColor(color) {
+		return nil, BadColorError
+	}
+	color, _ = NormalizeColor(color)
+
+	c := NewTextComment(text, color)
+
+	if c.Mode, ok = attr["mode"]; !ok {
+		c.Mode = style.DefaultMode
+	}
+	if (c.Mode != "" || len(style.Modes) > 0) && !style.allowMode(c.Mode) {
+		return nil, BadModeError
 	}
-	if len(text) == 0 {
-		return nil, false
+
+	if c.Size, ok = attr["size"]; !ok {
+		c.Size = style.DefaultSize
+	}
+	if (c.Size != "" || len(style.Sizes) > 0) && !style.allowSize(c.Size) {
+		return nil, BadSizeError
+	}
+
+	if speed, ok := attr["speed"]; ok {
+		v, err := strconv.ParseFloat(speed, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || v < TextSpeedMin || v > TextSpeedMax {
+			return nil, BadSpeedError
+		}
+		c.Speed = strconv.FormatFloat(v, 'f', -1, 64)
 	}
-	return NewTextComment(text, color), true
+	return c, nil
 }
 
 func NewTextComment(text string, color string) *TextComment {
@@ -48,9 +237,13 @@ func NewTextComment(text string, color string) *TextComment {
 	}
 }
 
+// text comment; mode, size and speed are optional and omitted from attributes when empty
 type TextComment struct {
 	Text  string
 	Color string
+	Mode  string
+	Size  string
+	Speed string
 }
 
 func (c *TextComment) Type() string {
@@ -62,7 +255,17 @@ func (c *TextComment) Content() string {
 }
 
 func (c *TextComment) Attributes() map[string]string {
-	return map[string]string{"color": c.Color}
+	attr := map[string]string{"color": c.Color}
+	if c.Mode != "" {
+		attr["mode"] = c.Mode
+	}
+	if c.Size != "" {
+		attr["size"] = c.Size
+	}
+	if c.Speed != "" {
+		attr["speed"] = c.Speed
+	}
+	return attr
 }
 
 /*
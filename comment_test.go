@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -23,3 +24,84 @@ func TestNewTextCommentFromMap(t *testing.T) {
 	}
 	assert.Equal(t, tc, tc2)
 }
+
+func TestNormalizeColor(t *testing.T) {
+	for in, out := range map[string]string{"Red": "red", "#FFF": "#fff", "#00ff00": "#00ff00"} {
+		c, ok := NormalizeColor(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, out, c)
+	}
+	for _, in := range []string{"", "reddish", "#ff", "#gggggg", "ff0000"} {
+		_, ok := NormalizeColor(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestNewStyledTextCommentFromMap(t *testing.T) {
+	tc, ok := NewTextCommentFromMap(map[string]string{
+		"text":  "hello\n\x1bworld",
+		"color": "#FF0000",
+		"mode":  "top",
+		"size":  "large",
+		"speed": "1.50",
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "hello world", tc.Content())
+	assert.Equal(t, map[string]string{"color": "#ff0000", "mode": "top", "size": "large", "speed": "1.5"}, tc.Attributes())
+
+	for _, tc := range []struct {
+		attr map[string]string
+		err  error
+	}{
+		{map[string]string{"color": "red"}, MissingTextError},
+		{map[string]string{"text": "\n\t\u200b", "color": "red"}, TextTooShortError},
+		{map[string]string{"text": strings.Repeat("a", TextDefaultMaxLength+1), "color": "red"}, TextTooLongError},
+		{map[string]string{"text": strings.Repeat("a", TextMaxBytes+1), "color": "red"}, TextTooLongError},
+		{map[string]string{"text": "hello", "color": "reddish"}, BadColorError},
+		{map[string]string{"text": "hello"}, BadColorError},
+		{map[string]string{"text": "hello", "color": "red", "mode": "sideways"}, BadModeError},
+		{map[string]string{"text": "hello", "color": "red", "size": "huge"}, BadSizeError},
+		{map[string]string{"text": "hello", "color": "red", "speed": "fast"}, BadSpeedError},
+		{map[string]string{"text": "hello", "color": "red", "speed": "10"}, BadSpeedError},
+		{map[string]string{"text": "hello", "color": "red", "speed": "NaN"}, BadSpeedError},
+		{map[string]string{"text": "hello", "color": "red", "speed": "-Inf"}, BadSpeedError},
+	} {
+		_, err := NewStyledTextCommentFromMap(tc.attr, DefaultTextStyle)
+		assert.Equal(t, tc.err, err, tc.attr)
+	}
+
+	_, err := NewStyledComment("picture", map[string]string{}, DefaultTextStyle)
+	assert.Equal(t, UnknownTypeError, err)
+
+	style := &TextStyle{
+		DefaultColor: "white",
+		DefaultMode:  TextModeScroll,
+		Modes:        []string{TextModeScroll, TextModeBottom},
+		MaxLength:    5,
+	}
+	assert.True(t, style.Valid())
+	tc, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello"}, style)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"color": "white", "mode": "scroll"}, tc.Attributes())
+
+	_, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello", "mode": "top"}, style)
+	assert.Equal(t, BadModeError, err)
+	_, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello!"}, style)
+	assert.Equal(t, TextTooLongError, err)
+	_, err = NewStyledTextCommentFromMap(map[string]string{"text": "hi"}, &TextStyle{DefaultColor: "red", MinLength: 3})
+	assert.Equal(t, TextTooShortError, err)
+
+	// restricted modes and sizes hold without defaults too
+	restricted := &TextStyle{DefaultColor: "red", Modes: []string{TextModeBottom}, Sizes: []string{TextSizeSmall}}
+	_, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello", "size": TextSizeSmall}, restricted)
+	assert.Equal(t, BadModeError, err)
+	_, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello", "mode": TextModeBottom}, restricted)
+	assert.Equal(t, BadSizeError, err)
+	tc, err = NewStyledTextCommentFromMap(map[string]string{"text": "hello", "mode": TextModeBottom, "size": TextSizeSmall}, restricted)
+	assert.Nil(t, err)
+	assert.Equal(t, TextModeBottom, tc.Mode)
+
+	assert.False(t, (&TextStyle{DefaultMode: TextModeTop, Modes: []string{TextModeScroll}}).Valid())
+	assert.False(t, (&TextStyle{Colors: []string{"reddish"}}).Valid())
+	assert.False(t, (&TextStyle{MinLength: 5, MaxLength: 3}).Valid())
+}
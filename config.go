@@ -0,0 +1,339 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ConfigEnvPrefix = "DANMAKU_"
+
+	minActivityTokenLength = 6
+	minAdminTokenLength    = 12
+)
+
+// activity created at startup; empty tokens are generated
+type ActivityConfig struct {
+	Name         string `yaml:"name"`
+	CommentToken string `yaml:"comment_token"`
+	ReviewToken  string `yaml:"review_token"`
+	DisplayToken string `yaml:"display_token"`
+}
+
+// server configuration; later sources override earlier ones:
+// defaults, config file, environment variables, command line flags
+type Config struct {
+	Listen               string           `yaml:"listen"`
+	AdminToken           string           `yaml:"admin_token"`
+	CORSOrigins          []string         `yaml:"cors_origins"`
+	CORSAdminOrigins     []string         `yaml:"cors_admin_origins"`
+	CORSMethods          []string         `yaml:"cors_methods"`
+	CORSCredentials      bool             `yaml:"cors_credentials"`
+	QueueLength          int              `yaml:"queue_length"`
+	ActivityTokenLength  int              `yaml:"activity_token_length"`
+	AdminTokenLength     int              `yaml:"admin_token_length"`
+	Demo                 bool             `yaml:"demo"`
+	Activities           []ActivityConfig `yaml:"activities"`
+	TLSCert              string           `yaml:"tls_cert"`
+	TLSKey               string           `yaml:"tls_key"`
+	TLSSelfSigned        bool             `yaml:"tls_self_signed"`
+	RedirectListen       string           `yaml:"redirect_listen"`
+	ShutdownNotice       time.Duration    `yaml:"shutdown_notice"`
+	ShutdownTimeout      time.Duration    `yaml:"shutdown_timeout"`
+	GRPCListen           string           `yaml:"grpc_listen"`
+	RetentionMaxComments int              `yaml:"retention_max_comments"`
+	RetentionTTL         time.Duration    `yaml:"retention_ttl"`
+	QueueMaxDepth        int              `yaml:"queue_max_depth"`
+	QueueOverflow        string           `yaml:"queue_overflow"`
+	PushKeyWindow        time.Duration    `yaml:"push_key_window"`
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Listen:               ":8881",
+		CORSOrigins:          []string{"*"},
+		CORSAdminOrigins:     []string{},
+		CORSMethods:          []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete},
+		QueueLength:          QueueDefaultLength,
+		ActivityTokenLength:  ActivityTokenLength,
+		AdminTokenLength:     AdminTokenLength,
+		ShutdownNotice:       3 * time.Second,
+		ShutdownTimeout:      10 * time.Second,
+		RetentionMaxComments: DefaultRetention.MaxComments,
+		RetentionTTL:         DefaultRetention.TTL,
+		QueueMaxDepth:        DefaultRetention.MaxQueueDepth,
+		QueueOverflow:        DefaultRetention.Overflow,
+		PushKeyWindow:        PushKeyDefaultWindow,
+	}
+}
+
+// a setting that can be given by flag or environment variable
+type configOption struct {
+	name   string
+	usage  string
+	set    func(c *Config, v string) error
+	isBool bool
+}
+
+func setString(field func(c *Config) *string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+func setList(field func(c *Config) *[]string) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		*field(c) = splitList(v)
+		return nil
+	}
+}
+
+func setInt(field func(c *Config) *int) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func setDuration(field func(c *Config) *time.Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func setBool(field func(c *Config) *bool) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+var configOptions = []configOption{
+	{"listen", "listen address", setString(func(c *Config) *string { return &c.Listen }), false},
+	{"admin-token", "admin token, generated when empty", setString(func(c *Config) *string { return &c.AdminToken }), false},
+	{"cors-origins", "comma separated CORS origins for comment and display clients", setList(func(c *Config) *[]string { return &c.CORSOrigins }), false},
+	{"cors-admin-origins", "comma separated CORS origins for admin and review clients", setList(func(c *Config) *[]string { return &c.CORSAdminOrigins }), false},
+	{"cors-methods", "comma separated CORS methods", setList(func(c *Config) *[]string { return &c.CORSMethods }), false},
+	{"cors-credentials", "allow credentials in CORS requests", setBool(func(c *Config) *bool { return &c.CORSCredentials }), true},
+	{"queue-length", "initial capacity of comment queues", setInt(func(c *Config) *int { return &c.QueueLength }), false},
+	{"activity-token-length", "length of generated activity tokens", setInt(func(c *Config) *int { return &c.ActivityTokenLength }), false},
+	{"admin-token-length", "length of the generated admin token", setInt(func(c *Config) *int { return &c.AdminTokenLength }), false},
+	{"demo", "create the insecure demo activity", setBool(func(c *Config) *bool { return &c.Demo }), true},
+	{"tls-cert", "TLS certificate file, reloaded on change", setString(func(c *Config) *string { return &c.TLSCert }), false},
+	{"tls-key", "TLS key file, reloaded on change", setString(func(c *Config) *string { return &c.TLSKey }), false},
+	{"tls-self-signed", "serve TLS with a generated self-signed certificate", setBool(func(c *Config) *bool { return &c.TLSSelfSigned }), true},
+	{"redirect-listen", "listen address redirecting HTTP to HTTPS", setString(func(c *Config) *string { return &c.RedirectListen }), false},
+	{"shutdown-notice", "time clients get to see the restart notice before shutdown", setDuration(func(c *Config) *time.Duration { return &c.ShutdownNotice }), false},
+	{"shutdown-timeout", "time in-flight requests get to finish on shutdown", setDuration(func(c *Config) *time.Duration { return &c.ShutdownTimeout }), false},
+	{"grpc-listen", "listen address of the gRPC service, off when empty", setString(func(c *Config) *string { return &c.GRPCListen }), false},
+	{"retention-max-comments", "finished comments kept per activity at most, 0 for no limit", setInt(func(c *Config) *int { return &c.RetentionMaxComments }), false},
+	{"retention-ttl", "time finished comments are kept, 0 for no limit", setDuration(func(c *Config) *time.Duration { return &c.RetentionTTL }), false},
+	{"queue-max-depth", "comments waiting in a queue at most, 0 for no limit", setInt(func(c *Config) *int { return &c.QueueMaxDepth }), false},
+	{"queue-overflow", "what a full queue does with a push: drop-oldest or reject", setString(func(c *Config) *string { return &c.QueueOverflow }), false},
+	{"push-key-window", "time a client push key is remembered to answer retries", setDuration(func(c *Config) *time.Duration { return &c.PushKeyWindow }), false},
+}
+
+// environment variable of an option, such as DANMAKU_ADMIN_TOKEN for admin-token
+func envName(option string) string {
+	return ConfigEnvPrefix + strings.ToUpper(strings.Replace(option, "-", "_", -1))
+}
+
+func splitList(v string) []string {
+	r := make([]string, 0)
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// flag.Value recording the raw value of an option, applied after file and environment
+type flagValue struct {
+	value string
+}
+
+func (f *flagValue) String() string     { return f.value }
+func (f *flagValue) Set(v string) error { f.value = v; return nil }
+
+// flag.Value for boolean options, so that -demo works without a value
+type boolFlagValue struct {
+	flagValue
+}
+
+func (f *boolFlagValue) IsBoolFlag() bool { return true }
+
+// load configuration from command line arguments, environment and the config file
+// given by -config or DANMAKU_CONFIG
+func LoadConfig(name string, args []string, getenv func(string) string) (*Config, error) {
+	c := DefaultConfig()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", getenv(envName("config")), "YAML config file")
+	for _, o := range configOptions {
+		var v flag.Value = &flagValue{}
+		if o.isBool {
+			v = &boolFlagValue{}
+		}
+		fs.Var(v, o.name, fmt.Sprintf("%s (env %s)", o.usage, envName(o.name)))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		b, err := ioutil.ReadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(b, c); err != nil {
+			return nil, fmt.Errorf("%s: %v", *path, err)
+		}
+	}
+
+	for _, o := range configOptions {
+		if v := getenv(envName(o.name)); v != "" {
+			if err := o.set(c, v); err != nil {
+				return nil, fmt.Errorf("%s: %v", envName(o.name), err)
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, o := range configOptions {
+			if o.name == f.Name && err == nil {
+				if e := o.set(c, f.Value.String()); e != nil {
+					err = fmt.Errorf("-%s: %v", o.name, e)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, c.Validate()
+}
+
+// check the configuration for unusable values
+func (c *Config) Validate() error {
+	switch {
+	case c.Listen == "":
+		return errors.New("listen address is empty")
+	case c.GRPCListen != "" && c.GRPCListen == c.Listen:
+		return errors.New("gRPC needs its own listen address")
+	case c.QueueLength <= 0:
+		return errors.New("queue length must be positive")
+	case c.ActivityTokenLength < minActivityTokenLength:
+		return fmt.Errorf("activity token length must be at least %d", minActivityTokenLength)
+	case c.AdminTokenLength < minAdminTokenLength:
+		return fmt.Errorf("admin token length must be at least %d", minAdminTokenLength)
+	case c.AdminToken != "" && len(c.AdminToken) < minAdminTokenLength:
+		return fmt.Errorf("admin token must be at least %d characters", minAdminTokenLength)
+	case (c.TLSCert == "") != (c.TLSKey == ""):
+		return errors.New("TLS certificate and key must be given together")
+	case c.TLSCert != "" && c.TLSSelfSigned:
+		return errors.New("TLS certificate and self-signed certificate are exclusive")
+	case c.RedirectListen != "" && !c.TLS():
+		return errors.New("HTTPS redirect requires TLS")
+	case c.ShutdownNotice < 0 || c.ShutdownTimeout < 0:
+		return errors.New("shutdown durations must not be negative")
+	case c.PushKeyWindow < 0:
+		return errors.New("push key window must not be negative")
+	case !c.Retention().Valid():
+		return fmt.Errorf("retention limits must not be negative and queue overflow one of %s", strings.Join(QueueOverflowPolicies, ", "))
+	}
+	for _, o := range append(c.CORSOrigins, c.CORSAdminOrigins...) {
+		if !ValidOrigin(o) {
+			return fmt.Errorf("invalid CORS origin %q", o)
+		}
+	}
+	for _, a := range c.Activities {
+		for _, t := range []string{a.CommentToken, a.ReviewToken, a.DisplayToken} {
+			if t != "" && len(t) < minActivityTokenLength {
+				return fmt.Errorf("token of activity %q must be at least %d characters", a.Name, minActivityTokenLength)
+			}
+		}
+	}
+	return nil
+}
+
+// CORS policies, admin and review clients only get the admin origins
+func (c *Config) CORS() *CORSConfig {
+	return &CORSConfig{
+		Public:  CORSPolicy{Origins: c.CORSOrigins, Methods: c.CORSMethods, Credentials: c.CORSCredentials},
+		Private: CORSPolicy{Origins: c.CORSAdminOrigins, Methods: c.CORSMethods, Credentials: c.CORSCredentials},
+	}
+}
+
+// retention of new activities, also the loosest an activity may set
+func (c *Config) Retention() RetentionPolicy {
+	return RetentionPolicy{
+		MaxComments:   c.RetentionMaxComments,
+		TTL:           c.RetentionTTL,
+		MaxQueueDepth: c.QueueMaxDepth,
+		Overflow:      c.QueueOverflow,
+	}
+}
+
+// whether the server listens with TLS
+func (c *Config) TLS() bool {
+	return c.TLSCert != "" || c.TLSSelfSigned
+}
+
+// set the package wide sizes
+func (c *Config) Apply() {
+	QueueDefaultLength = c.QueueLength
+	ActivityTokenLength = c.ActivityTokenLength
+	AdminTokenLength = c.AdminTokenLength
+	DefaultRetention = c.Retention()
+	PushKeyDefaultWindow = c.PushKeyWindow
+}
+
+// create an engine with the configured admin token and seeded activities
+func (c *Config) NewEngine() (*Engine, error) {
+	e := NewEngine()
+	if c.AdminToken != "" {
+		e.AdminToken = c.AdminToken
+	}
+	acts := c.Activities
+	if c.Demo {
+		acts = append([]ActivityConfig{{"Test Activity", "cc123456", "rr123456", "dd123456"}}, acts...)
+	}
+	for _, a := range acts {
+		tokens := []string{a.CommentToken, a.ReviewToken, a.DisplayToken}
+		for i := range tokens {
+			if tokens[i] == "" {
+				tokens[i] = e.newToken()
+			}
+		}
+		if _, err := e.NewActivityFull(e.AdminToken, a.Name, tokens[0], tokens[1], tokens[2]); err != nil {
+			return nil, fmt.Errorf("activity %q: %v", a.Name, err)
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	c, err := LoadConfig("test", nil, func(string) string { return "" })
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultConfig(), c)
+	assert.False(t, c.Demo)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	ioutil.WriteFile(path, []byte(`
+listen: ":9000"
+admin_token: "file-admin-token"
+queue_length: 50
+cors_origins: ["https://a.example"]
+activities:
+  - name: Keynote
+    comment_token: kk123456
+`), 0600)
+
+	env := map[string]string{
+		"DANMAKU_CONFIG":       path,
+		"DANMAKU_QUEUE_LENGTH": "60",
+		"DANMAKU_CORS_ORIGINS": "https://b.example, https://c.example",
+	}
+	c, err = LoadConfig("test", []string{"-queue-length", "70", "-demo"}, func(k string) string { return env[k] })
+	assert.Nil(t, err)
+	assert.Equal(t, ":9000", c.Listen)
+	assert.Equal(t, "file-admin-token", c.AdminToken)
+	assert.Equal(t, []string{"https://b.example", "https://c.example"}, c.CORSOrigins)
+	assert.Equal(t, 70, c.QueueLength)
+	assert.True(t, c.Demo)
+	assert.Equal(t, []ActivityConfig{{Name: "Keynote", CommentToken: "kk123456"}}, c.Activities)
+
+	_, err = LoadConfig("test", []string{"-queue-length", "many"}, func(string) string { return "" })
+	assert.Error(t, err)
+	_, err = LoadConfig("test", []string{"-admin-token", "short"}, func(string) string { return "" })
+	assert.Error(t, err)
+	_, err = LoadConfig("test", nil, func(k string) string { return map[string]string{"DANMAKU_CONFIG": path + ".missing"}[k] })
+	assert.Error(t, err)
+}
+
+func TestConfig_NewEngine(t *testing.T) {
+	c := DefaultConfig()
+	e, err := c.NewEngine()
+	assert.Nil(t, err)
+	acts, _ := e.Activities(e.AdminToken)
+	assert.Equal(t, 0, len(acts))
+
+	c.AdminToken = "configured-admin"
+	c.Demo = true
+	c.Activities = []ActivityConfig{{Name: "Keynote", CommentToken: "kk123456"}}
+	e, err = c.NewEngine()
+	assert.Nil(t, err)
+	assert.Equal(t, "configured-admin", e.AdminToken)
+	acts, _ = e.Activities(e.AdminToken)
+	assert.Equal(t, 2, len(acts))
+	act, ok := e.ActivityByToken("kk123456")
+	assert.True(t, ok)
+	assert.Equal(t, "Keynote", act.Settings().Name)
+	assert.Equal(t, ActivityTokenLength, len(act.ReviewToken))
+	_, ok = e.ActivityByToken("cc123456")
+	assert.True(t, ok)
+
+	c.Activities = append(c.Activities, ActivityConfig{Name: "Again", CommentToken: "kk123456"})
+	_, err = c.NewEngine()
+	assert.Error(t, err)
+}
+
+func TestConfig_ValidateTLS(t *testing.T) {
+	c := DefaultConfig()
+	c.TLSCert = "cert.pem"
+	assert.Error(t, c.Validate())
+	c.TLSKey = "key.pem"
+	assert.Nil(t, c.Validate())
+	c.TLSSelfSigned = true
+	assert.Error(t, c.Validate())
+
+	c = DefaultConfig()
+	c.RedirectListen = ":80"
+	assert.Error(t, c.Validate())
+	c.TLSSelfSigned = true
+	assert.Nil(t, c.Validate())
+	assert.True(t, c.TLS())
+}
+
+func TestLoadConfig_Shutdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	ioutil.WriteFile(path, []byte("shutdown_timeout: 30s\n"), 0600)
+	c, err := LoadConfig("test", []string{"-config", path, "-shutdown-notice", "1s"}, func(string) string { return "" })
+	assert.Nil(t, err)
+	assert.Equal(t, time.Second, c.ShutdownNotice)
+	assert.Equal(t, 30*time.Second, c.ShutdownTimeout)
+
+	_, err = LoadConfig("test", []string{"-shutdown-timeout", "-1s"}, func(string) string { return "" })
+	assert.Error(t, err)
+}
+
+func TestLoadConfig_Retention(t *testing.T) {
+	c, err := LoadConfig("test", []string{"-queue-max-depth", "50", "-queue-overflow", "reject", "-retention-ttl", "10m"}, func(string) string { return "" })
+	assert.Nil(t, err)
+	assert.Equal(t, RetentionPolicy{MaxComments: DefaultRetention.MaxComments, TTL: 10 * time.Minute, MaxQueueDepth: 50, Overflow: OverflowReject}, c.Retention())
+
+	_, err = LoadConfig("test", []string{"-queue-overflow", "ignore"}, func(string) string { return "" })
+	assert.Error(t, err)
+	_, err = LoadConfig("test", []string{"-retention-max-comments", "-1"}, func(string) string { return "" })
+	assert.Error(t, err)
+}
+
+func TestConfig_CORS(t *testing.T) {
+	c, err := LoadConfig("test", []string{"-cors-admin-origins", "https://admin.example", "-cors-credentials"}, func(string) string { return "" })
+	assert.Nil(t, err)
+	cors := c.CORS()
+	assert.Equal(t, []string{"*"}, cors.Public.Origins)
+	assert.Equal(t, []string{"https://admin.example"}, cors.Private.Origins)
+	assert.True(t, cors.Private.Credentials)
+	// the REST admin routes need more than GET and POST
+	assert.Equal(t, []string{"GET", "POST", "PATCH", "DELETE"}, cors.Private.Methods)
+
+	_, err = LoadConfig("test", []string{"-cors-origins", "fan.example"}, func(string) string { return "" })
+	assert.Error(t, err)
+}
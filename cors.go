@@ -0,0 +1,160 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	CORSMaxAge = 600
+)
+
+var (
+	corsAllowedHeaders = []string{"Content-Type", "Authorization", "X-Danmaku-Client"}
+)
+
+// allowed origins, methods and credentials of cross-origin requests
+type CORSPolicy struct {
+	Origins     []string
+	Methods     []string
+	Credentials bool
+}
+
+// public applies to comment and display tokens, private to admin and review tokens
+type CORSConfig struct {
+	Public  CORSPolicy
+	Private CORSPolicy
+}
+
+// check an origin as used in CORS, "*" or scheme://host[:port] without path
+func ValidOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && (u.Path == "" || u.Path == "/")
+}
+
+func (p *CORSPolicy) allowOrigin(origin string) bool {
+	for _, o := range p.Origins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// a request from the page served by this host and scheme is not cross-origin
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Scheme, requestScheme(r)) && strings.EqualFold(u.Host, r.Host)
+}
+
+// scheme the client used, https when served over TLS or when a proxy terminating TLS
+// says so in X-Forwarded-Proto
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	if proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ","); strings.TrimSpace(proto) != "" {
+		return strings.ToLower(strings.TrimSpace(proto))
+	}
+	return "http"
+}
+
+// policy for the token of a JSON-RPC request; an activity with its own origins
+// replaces the public origins for its comment and display tokens
+func (c *CORSConfig) policyFor(e *Engine, token string) CORSPolicy {
+	role, _ := e.Login(token)
+	if role == "admin" || role == "review" {
+		return c.Private
+	}
+	p := c.Public
+	if act, ok := e.ActivityByToken(token); ok {
+		if origins := act.Settings().Origins; len(origins) > 0 {
+			p.Origins = origins
+		}
+	}
+	return p
+}
+
+// whether any policy could allow the origin, used for preflights which carry no token
+func (c *CORSConfig) anyAllows(e *Engine, origin string) bool {
+	if c.Public.allowOrigin(origin) || c.Private.allowOrigin(origin) {
+		return true
+	}
+	acts, _ := e.Activities(e.AdminToken)
+	for _, act := range acts {
+		p := CORSPolicy{Origins: act.Settings().Origins}
+		if p.allowOrigin(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func setAllowOrigin(w http.ResponseWriter, origin string, credentials bool) {
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// wrap a JSON-RPC or REST handler with CORS; preflights are answered for any origin some policy allows,
+// actual requests are checked against the policy of their token and refused before running
+// when the origin is not allowed
+func NewCORSHandler(e *Engine, c *CORSConfig, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" || sameOrigin(r, origin) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			method := r.Header.Get("Access-Control-Request-Method")
+			if !c.anyAllows(e, origin) || !containsString(append(c.Public.Methods, c.Private.Methods...), method) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			setAllowOrigin(w, origin, c.Public.Credentials || c.Private.Credentials)
+			w.Header().Set("Access-Control-Allow-Methods", method)
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(corsAllowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(CORSMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// every call of a JSON-RPC batch must be allowed
+		tokens := []string{bearerToken(r)}
+		if tokens[0] == "" {
+			tokens = tokens[:0]
+			for _, req := range peekRequests(r) {
+				tokens = append(tokens, req.Token)
+			}
+		}
+		if len(tokens) == 0 {
+			tokens = append(tokens, "")
+		}
+		credentials := true
+		for _, token := range tokens {
+			p := c.policyFor(e, token)
+			if !p.allowOrigin(origin) || !containsString(p.Methods, r.Method) {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			credentials = credentials && p.Credentials
+		}
+		setAllowOrigin(w, origin, credentials)
+		h.ServeHTTP(w, r)
+	})
+}
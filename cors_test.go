@@ -0,0 +1,122 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidOrigin(t *testing.T) {
+	for _, o := range []string{"*", "https://a.example", "http://localhost:8080", "https://a.example/"} {
+		assert.True(t, ValidOrigin(o), o)
+	}
+	for _, o := range []string{"", "a.example", "ftp://a.example", "https://a.example/path", "https://u@a.example"} {
+		assert.False(t, ValidOrigin(o), o)
+	}
+}
+
+func TestCORSHandler(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	embedded, _ := e.NewActivity(e.AdminToken, "Embedded")
+	assert.Equal(t, IllFormatError, e.SetOrigins(e.AdminToken, embedded.Id, []string{"embed.example"}))
+	assert.Nil(t, e.SetOrigins(e.AdminToken, embedded.Id, []string{"https://embed.example"}))
+
+	c := &CORSConfig{
+		Public:  CORSPolicy{Origins: []string{"*"}, Methods: []string{"POST"}},
+		Private: CORSPolicy{Origins: []string{"https://admin.example"}, Methods: []string{"POST"}, Credentials: true},
+	}
+	called := false
+	h := NewCORSHandler(e, c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	call := func(origin string, token string) *httptest.ResponseRecorder {
+		called = false
+		r := httptest.NewRequest("POST", "http://danmaku.example/", strings.NewReader(`{"method":"DanmakuService.Push","params":[{"Token":"`+token+`"}]}`))
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// public tokens from anywhere
+	w := call("https://fan.example", act.CommentToken)
+	assert.True(t, called)
+	assert.Equal(t, "https://fan.example", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// admin and review tokens only from admin origins
+	w = call("https://fan.example", act.ReviewToken)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	call("https://fan.example", e.AdminToken)
+	assert.False(t, called)
+	w = call("https://admin.example", e.AdminToken)
+	assert.True(t, called)
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	// a batch is allowed only if each of its calls is
+	batch := func(tokens ...string) {
+		called = false
+		calls := make([]string, len(tokens))
+		for i, token := range tokens {
+			calls[i] = `{"method":"DanmakuService.Push","params":[{"Token":"` + token + `"}]}`
+		}
+		r := httptest.NewRequest("POST", "http://danmaku.example/", strings.NewReader("["+strings.Join(calls, ",")+"]"))
+		r.Header.Set("Origin", "https://fan.example")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	batch(act.CommentToken, act.DisplayToken)
+	assert.True(t, called)
+	batch(act.CommentToken, e.AdminToken)
+	assert.False(t, called)
+
+	// same origin and non-browser requests pass
+	call("http://danmaku.example", e.AdminToken)
+	assert.True(t, called)
+	call("", e.AdminToken)
+	assert.True(t, called)
+
+	// the scheme counts too, as served or as forwarded by a proxy terminating TLS
+	same := func(origin string, proto string) {
+		called = false
+		r := httptest.NewRequest("POST", "http://danmaku.example/", strings.NewReader(`{"method":"DanmakuService.Push","params":[{"Token":"`+e.AdminToken+`"}]}`))
+		r.Header.Set("Origin", origin)
+		if proto != "" {
+			r.Header.Set("X-Forwarded-Proto", proto)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	same("https://danmaku.example", "")
+	assert.False(t, called)
+	same("https://danmaku.example", "https")
+	assert.True(t, called)
+	same("http://danmaku.example", "https")
+	assert.False(t, called)
+
+	// activity origins replace the public ones
+	call("https://fan.example", embedded.CommentToken)
+	assert.False(t, called)
+	call("https://embed.example", embedded.CommentToken)
+	assert.True(t, called)
+
+	preflight := func(origin string, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("OPTIONS", "http://danmaku.example/", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	w = preflight("https://fan.example", "POST")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://fan.example", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusForbidden, preflight("https://fan.example", "DELETE").Code)
+
+	c.Public.Origins = []string{"https://public.example"}
+	assert.Equal(t, http.StatusNoContent, preflight("https://embed.example", "POST").Code)
+	assert.Equal(t, http.StatusForbidden, preflight("https://fan.example", "POST").Code)
+}
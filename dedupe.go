@@ -0,0 +1,303 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// what an activity does with duplicate comments
+const (
+	DedupeOff      = "off"
+	DedupeCollapse = "collapse"
+	DedupeDeny     = "deny"
+)
+
+const (
+	DedupeMaxWindow   = 10 * time.Minute
+	DedupeMaxDistance = 5
+	// shortest and longest keys compared by edit distance, others must be equal
+	DedupeMinFuzzyLength = 4
+	DedupeMaxFuzzyLength = 64
+	// distinct texts remembered per activity, the least recently seen go first
+	DedupeMaxGroups = 256
+	// runs of a repeated character longer than this are shortened to it
+	dedupeMaxRun = 3
+)
+
+// longest wait for the push holding a group; past it the text is taken for a new one
+// without holding its group
+var DedupeMaxWait = 5 * time.Second
+
+var DedupeModes = []string{DedupeOff, DedupeCollapse, DedupeDeny}
+
+// duplicate suppression of an activity: text comments whose keys are equal, or within Distance
+// edits of each other but no more than one per four characters of the shorter key, are
+// duplicates while pushed within Window of the last one of them.
+// Collapse counts a duplicate on the first comment as long as it waits for review or display,
+// deny pushes it and denies it at once
+type DedupePolicy struct {
+	Mode     string
+	Window   time.Duration
+	Distance int
+}
+
+func (p DedupePolicy) Valid() bool {
+	return containsString(DedupeModes, p.Mode) &&
+		(p.Window > 0 || !p.on()) && p.Window >= 0 && p.Window <= DedupeMaxWindow &&
+		p.Distance >= 0 && p.Distance <= DedupeMaxDistance
+}
+
+func (p DedupePolicy) on() bool {
+	return p.Mode == DedupeCollapse || p.Mode == DedupeDeny
+}
+
+// key of a text for comparison: NFKC normalized, case folded, letters and digits
+// only, long runs of one character shortened, so that "666!!", "6666666" and "６６６" share the
+// key "666"; texts without letters or digits, such as emoji, keep their other characters
+func DedupeKey(text string) []rune {
+	text = strings.ToLower(norm.NFKC.String(NormalizeText(text)))
+	key := make([]rune, 0, len(text))
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			key = append(key, r)
+		}
+	}
+	if len(key) == 0 {
+		for _, r := range text {
+			if !unicode.IsSpace(r) {
+				key = append(key, r)
+			}
+		}
+	}
+	return shortenRuns(key)
+}
+
+func shortenRuns(key []rune) []rune {
+	out := key[:0]
+	run := 0
+	for i, r := range key {
+		if i > 0 && r == key[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run <= dedupeMaxRun {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// edit distance of a and b if at most max, otherwise max+1
+func editDistance(a []rune, b []rune, max int) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(a)-len(b) > max {
+		return max + 1
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			best = min(best, cur[j])
+		}
+		if best > max {
+			return max + 1
+		}
+		prev, cur = cur, prev
+	}
+	return min(prev[len(b)], max+1)
+}
+
+// comments sharing a key; lc is the one duplicates are counted on. A group is reserved by
+// the push that starts or takes it over until the comment is added: ready is then closed
+type dedupeGroup struct {
+	key   []rune
+	last  time.Time
+	lc    *LabelComment
+	ready chan struct{} // nil once filled
+}
+
+func NewDedupeBoard() *DedupeBoard {
+	return &DedupeBoard{
+		exact: make(map[string]*dedupeGroup),
+		now:   time.Now,
+	}
+}
+
+// DedupeBoard remembers the keys of recent text comments of an activity
+type DedupeBoard struct {
+	mutex  sync.Mutex
+	groups []*dedupeGroup // least recently seen first
+	exact  map[string]*dedupeGroup
+	Count  int
+	now    func() time.Time
+}
+
+// forget groups not seen within the window or beyond the maximum; must hold the mutex
+func (b *DedupeBoard) expire(window time.Duration) {
+	now := b.now()
+	n := 0
+	for n < len(b.groups) && (now.Sub(b.groups[n].last) >= window || len(b.groups)-n > DedupeMaxGroups) {
+		delete(b.exact, string(b.groups[n].key))
+		b.groups[n] = nil
+		n++
+	}
+	b.groups = b.groups[n:]
+}
+
+// group of a key, or nil; must hold the mutex
+func (b *DedupeBoard) find(key []rune, distance int) *dedupeGroup {
+	if g, ok := b.exact[string(key)]; ok {
+		return g
+	}
+	if distance == 0 || len(key) < DedupeMinFuzzyLength || len(key) > DedupeMaxFuzzyLength {
+		return nil
+	}
+	for i := len(b.groups) - 1; i >= 0; i-- {
+		g := b.groups[i]
+		if len(g.key) < DedupeMinFuzzyLength || len(g.key) > DedupeMaxFuzzyLength {
+			continue
+		}
+		d := fuzzyDistance(key, g.key, distance)
+		if d > 0 && editDistance(key, g.key, d) <= d {
+			return g
+		}
+	}
+	return nil
+}
+
+// edits allowed between two keys, scaled to the shorter one so that short texts such as
+// "lol" and "wow" are not taken for each other
+func fuzzyDistance(a []rune, b []rune, distance int) int {
+	return min(distance, min(len(a), len(b))/4)
+}
+
+// move a group to the most recently seen end; must hold the mutex
+func (b *DedupeBoard) touch(g *dedupeGroup) {
+	g.last = b.now()
+	for i, h := range b.groups {
+		if h == g {
+			copy(b.groups[i:], b.groups[i+1:])
+			b.groups[len(b.groups)-1] = g
+			return
+		}
+	}
+	b.groups = append(b.groups, g)
+	b.exact[string(g.key)] = g
+}
+
+// comment of the group of a text within the window if collapse takes the duplicate on it,
+// nil collapse taking all of them; the group is seen again. Otherwise the text starts its
+// group, or takes it over, and fill must be called with the comment added for the text, or
+// nil if none was; until then pushes of the group wait, up to DedupeMaxWait, so that
+// duplicates pushed at once are taken for duplicates
+func (b *DedupeBoard) Match(p DedupePolicy, text string, collapse func(lc *LabelComment) bool) (*LabelComment, func(lc *LabelComment)) {
+	key := DedupeKey(text)
+	var timeout <-chan time.Time
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for {
+		b.expire(p.Window)
+		g := b.find(key, p.Distance)
+		if g == nil {
+			g = &dedupeGroup{key: key}
+		} else if ready := g.ready; ready != nil {
+			if timeout == nil {
+				t := time.NewTimer(DedupeMaxWait)
+				defer t.Stop()
+				timeout = t.C
+			}
+			b.mutex.Unlock()
+			select {
+			case <-ready:
+			case <-timeout:
+				b.mutex.Lock()
+				return nil, nil
+			}
+			b.mutex.Lock()
+			continue
+		}
+		b.touch(g)
+		if g.lc != nil && (collapse == nil || collapse(g.lc)) {
+			return g.lc, nil
+		}
+		g.lc = nil
+		g.ready = make(chan struct{})
+		return nil, func(lc *LabelComment) { b.fill(g, lc) }
+	}
+}
+
+// end the reservation of a group, dropping the group without a comment
+func (b *DedupeBoard) fill(g *dedupeGroup, lc *LabelComment) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if g.ready == nil {
+		return
+	}
+	close(g.ready)
+	g.ready = nil
+	g.lc = lc
+	if lc != nil {
+		return
+	}
+	for i, h := range b.groups {
+		if h == g {
+			b.groups = append(b.groups[:i:i], b.groups[i+1:]...)
+			break
+		}
+	}
+	if b.exact[string(g.key)] == g {
+		delete(b.exact, string(g.key))
+	}
+}
+
+// count a suppressed duplicate
+func (b *DedupeBoard) Suppressed() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.Count++
+}
+
+func (b *DedupeBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, g := range b.groups {
+		if g.ready != nil {
+			close(g.ready)
+			g.ready = nil
+		}
+	}
+	b.groups = nil
+	b.exact = make(map[string]*dedupeGroup)
+	b.Count = 0
+}
+
+// number of suppressed duplicates
+func (b *DedupeBoard) Duplicates() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.Count
+}
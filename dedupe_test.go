@@ -0,0 +1,150 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDedupeKey(t *testing.T) {
+	assert.Equal(t, "666", string(DedupeKey("666")))
+	assert.Equal(t, "666", string(DedupeKey(" 6666666!! ")))
+	assert.Equal(t, "666", string(DedupeKey("６６６")))
+	assert.Equal(t, "hello", string(DedupeKey("Hello!")))
+	assert.Equal(t, "哈哈哈", string(DedupeKey("哈哈哈哈哈")))
+	assert.Equal(t, "👏👏👏", string(DedupeKey("👏 👏 👏 👏")))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance([]rune("abc"), []rune("abc"), 2))
+	assert.Equal(t, 1, editDistance([]rune("abc"), []rune("abd"), 2))
+	assert.Equal(t, 2, editDistance([]rune("abc"), []rune("a"), 2))
+	assert.Equal(t, 3, editDistance([]rune("abc"), []rune("xyz"), 2))
+	assert.Equal(t, 3, editDistance([]rune("abcdef"), []rune("a"), 2))
+}
+
+func TestDedupePolicy_Valid(t *testing.T) {
+	assert.True(t, DedupePolicy{Mode: DedupeOff}.Valid())
+	assert.True(t, DedupePolicy{Mode: DedupeCollapse, Window: time.Second, Distance: 1}.Valid())
+	assert.False(t, DedupePolicy{Mode: DedupeCollapse}.Valid())
+	assert.False(t, DedupePolicy{Mode: "merge", Window: time.Second}.Valid())
+	assert.False(t, DedupePolicy{Mode: DedupeDeny, Window: DedupeMaxWindow + 1}.Valid())
+	assert.False(t, DedupePolicy{Mode: DedupeDeny, Window: time.Second, Distance: DedupeMaxDistance + 1}.Valid())
+}
+
+func TestDedupeBoard(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := NewDedupeBoard()
+	b.now = func() time.Time { return now }
+	p := DedupePolicy{Mode: DedupeCollapse, Window: 5 * time.Second, Distance: 1}
+	id := 0
+	// what the dedupe stage does, with waiting telling whether the comment matched still waits
+	push := func(text string, waiting bool) *LabelComment {
+		orig, fill := b.Match(p, text, func(*LabelComment) bool { return waiting })
+		if orig != nil {
+			b.Suppressed()
+			return orig
+		}
+		id++
+		lc := &LabelComment{Id: id, Content: text}
+		fill(lc)
+		return lc
+	}
+
+	first := push("go go go", true)
+	assert.Same(t, first, push("GO GO GO!", true))
+	assert.Same(t, first, push("go go g", true))
+	other := push("stop", true)
+	assert.NotSame(t, first, other)
+	assert.Equal(t, 2, b.Duplicates())
+
+	// the window slides with each duplicate
+	now = now.Add(4 * time.Second)
+	assert.Same(t, first, push("gogogo", true))
+	now = now.Add(5 * time.Second)
+	assert.NotSame(t, first, push("gogogo", true))
+
+	// a comment no longer waiting hands its group to the next one
+	next := push("stop", false)
+	assert.NotSame(t, other, next)
+	assert.Same(t, next, push("stop", true))
+
+	b.Reset()
+	assert.Equal(t, 0, b.Duplicates())
+}
+
+func TestDedupeBoard_ShortKeys(t *testing.T) {
+	b := NewDedupeBoard()
+	remember := func(p DedupePolicy, text string) {
+		orig, fill := b.Match(p, text, nil)
+		assert.Nil(t, orig)
+		fill(&LabelComment{Content: text})
+	}
+	// whether a text matches without taking its group
+	match := func(p DedupePolicy, text string) bool {
+		orig, fill := b.Match(p, text, nil)
+		if fill != nil {
+			fill(nil)
+		}
+		return orig != nil
+	}
+
+	p := DedupePolicy{Mode: DedupeDeny, Window: time.Minute, Distance: 1}
+	remember(p, "好")
+	assert.False(t, match(p, "哈"))
+	assert.True(t, match(p, "好"))
+
+	p.Distance = 3
+	remember(p, "666")
+	remember(p, "lol")
+	remember(p, "wow")
+	assert.True(t, match(p, "wow!"))
+
+	// one edit per four characters of the shorter key
+	remember(p, "哈哈哈好好好")
+	assert.True(t, match(p, "哈哈哈好好"))
+	assert.False(t, match(p, "哈哈好好"))
+	remember(p, "what a great goal")
+	assert.True(t, match(p, "what a graet goal"))
+	assert.False(t, match(p, "what a late goat"))
+}
+
+func TestDedupeBoard_Reserve(t *testing.T) {
+	b := NewDedupeBoard()
+	p := DedupePolicy{Mode: DedupeDeny, Window: time.Minute}
+	orig, fill := b.Match(p, "666", nil)
+	assert.Nil(t, orig)
+
+	// the same text waits for the first one to be added
+	matched := make(chan *LabelComment)
+	go func() {
+		orig, _ := b.Match(p, "666", nil)
+		matched <- orig
+	}()
+	select {
+	case <-matched:
+		t.Fatal("matched a reserved group")
+	case <-time.After(20 * time.Millisecond):
+	}
+	first := &LabelComment{Id: 1, Content: "666"}
+	fill(first)
+	assert.Same(t, first, <-matched)
+
+	// past the longest wait the text is taken for a new one
+	defer func(d time.Duration) { DedupeMaxWait = d }(DedupeMaxWait)
+	DedupeMaxWait = 10 * time.Millisecond
+	orig, fill = b.Match(p, "888", nil)
+	assert.Nil(t, orig)
+	orig, next := b.Match(p, "888", nil)
+	assert.Nil(t, orig)
+	assert.Nil(t, next)
+	fill(nil)
+
+	// a comment not added leaves the group to the next one
+	orig, fill = b.Match(p, "777", nil)
+	assert.Nil(t, orig)
+	fill(nil)
+	orig, fill = b.Match(p, "777", nil)
+	assert.Nil(t, orig)
+	assert.NotNil(t, fill)
+}
@@ -4,53 +4,224 @@
 package main
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"github.com/antenna3mt/rpc/json"
 	"net/http"
 )
 
-const (
+// lengths of generated tokens, set by configuration
+var (
 	ActivityTokenLength = 8
 	AdminTokenLength    = 16
 )
 
+// most comments of a batch push or calls of a JSON-RPC batch
+var BatchMaxLength = 100
+
+// length of the random part of a client id
+const ClientIdLength = 16
+
 var (
-	NotAuthorizedError = &json.Error{Code: http.StatusUnauthorized, Message: "not authorized",}
-	NotExistError      = &json.Error{Code: http.StatusNotFound, Message: "not exist",}
-	IllFormatError     = &json.Error{Code: http.StatusBadRequest, Message: "ill format",}
-	AlreadyExistError  = &json.Error{Code: http.StatusConflict, Message: "alread exist",}
+	NotAuthorizedError   = &json.Error{Code: http.StatusUnauthorized, Message: "not authorized",}
+	NotExistError        = &json.Error{Code: http.StatusNotFound, Message: "not exist",}
+	IllFormatError       = &json.Error{Code: http.StatusBadRequest, Message: "ill format",}
+	AlreadyExistError    = &json.Error{Code: http.StatusConflict, Message: "alread exist",}
+	ShuttingDownError    = &json.Error{Code: http.StatusServiceUnavailable, Message: "shutting down",}
+	QueueFullError       = &json.Error{Code: http.StatusTooManyRequests, Message: "queue full",}
+	BatchTooLongError    = &json.Error{Code: http.StatusRequestEntityTooLarge, Message: "batch too long",}
+	TooManyWebhooksError = &json.Error{Code: http.StatusBadRequest, Message: "too many webhooks",}
+	InvalidClientError   = &json.Error{Code: http.StatusBadRequest, Message: "invalid client",}
+	RateLimitedError     = &json.Error{Code: http.StatusTooManyRequests, Message: "rate limited",}
 )
 
-// activity extend BasicActivity
+// settings of an activity the admin may change while comments flow;
+// never modified in place, updates replace the whole value
+type ActivitySettings struct {
+	Name      string
+	ReviewOn  bool
+	Mode      string
+	Origins   []string
+	TextStyle  *TextStyle
+	Dedupe     DedupePolicy
+	Moderation ModerationChain
+}
+
+// activity extend BasicActivity; id and tokens never change after creation
 type Activity struct {
 	BasicActivity
-	Id           int
-	Name         string
-	CommentToken string
-	ReviewToken  string
-	DisplayToken string
-	ReviewOn     bool
+	Id            int
+	CommentToken  string
+	ReviewToken   string
+	DisplayToken  string
+	Reactions     *ReactionBoard
+	Polls         *PollBoard
+	Questions     *QuestionBoard
+	PushKeys      *PushKeyBoard
+	Dedupe        *DedupeBoard
+	Webhooks      *WebhookBoard
+	Reputation    *ReputationBoard
+	settings      atomic.Pointer[ActivitySettings]
+	settingsMutex sync.Mutex
+}
+
+// current settings, safe to read without locking
+func (act *Activity) Settings() *ActivitySettings {
+	return act.settings.Load()
+}
+
+// apply f to a copy of the settings and publish it; updates are serialized
+func (act *Activity) updateSettings(f func(s *ActivitySettings)) {
+	act.settingsMutex.Lock()
+	defer act.settingsMutex.Unlock()
+
+	s := *act.settings.Load()
+	f(&s)
+	act.settings.Store(&s)
+}
+
+// approve comments; in Q&A mode they become questions instead of being queued for displaying
+func (act *Activity) approve(lcs []*LabelComment) {
+	if len(lcs) == 0 {
+		return
+	}
+	if act.Settings().Mode == ActivityModeQA {
+		act.ApproveUnqueued(lcs)
+		act.Questions.Add(lcs)
+	} else {
+		act.Approve(lcs)
+	}
+	act.decided(lcs, nil)
+}
+
+// tell reputations and webhooks about comments approved and denied
+func (act *Activity) decided(approved []*LabelComment, denied []*LabelComment) {
+	act.Reputation.Record(approved, denied)
+	if len(approved) > 0 {
+		act.Webhooks.Emit(EventCommentApproved, approved)
+	}
+	if len(denied) > 0 {
+		act.Webhooks.Emit(EventCommentDenied, denied)
+	}
+}
+
+// build a comment with the activity text style, moderate it and queue it unless denied,
+// collapsed or throttled, throttled ones being refused without a trace; comments held by
+// moderation wait for review even when review is off, the ones it approved skip review
+func (act *Activity) add(s *Sender, tp string, attr map[string]string) (*LabelComment, error) {
+	settings := act.Settings()
+	c, err := NewStyledComment(tp, attr, settings.TextStyle)
+	if err != nil {
+		return nil, err
+	}
+
+	m := settings.Moderation.Moderate(act, c, s)
+	defer m.Done(nil)
+	if v, ok := m.Final(); ok {
+		switch v.Action {
+		case VerdictCollapse:
+			return v.Into, nil
+		case VerdictThrottle:
+			return nil, RateLimitedError
+		}
+		lc := act.AddDenied(m.Comment, s.id(), m.Verdicts...)
+		act.Webhooks.Emit(EventCommentCreated, []*LabelComment{lc})
+		act.decided(nil, []*LabelComment{lc})
+		return lc, nil
+	}
+
+	lc := act.AddFrom(m.Comment, s.id(), m.Verdicts...)
+	m.Done(lc)
+	if lc == nil {
+		return nil, QueueFullError
+	}
+	act.Webhooks.Emit(EventCommentCreated, []*LabelComment{lc})
+
+	switch {
+	case !settings.ReviewOn:
+		act.approve(act.ReviewWhere(func(c *LabelComment) bool { return !c.Held() }))
+	case lc.Passed():
+		act.approve(act.ReviewWhere((*LabelComment).Passed))
+	}
+
+	return lc, nil
+}
+
+// approve and deny at once, approved questions go to the question board instead of the approved queue
+func (act *Activity) decide(approved []*LabelComment, denied []*LabelComment) {
+	qa := act.Settings().Mode == ActivityModeQA
+	act.Decide(approved, denied, qa)
+	if qa {
+		act.Questions.Add(approved)
+	}
+	act.decided(approved, denied)
 }
 
 func NewEngine() *Engine {
+	hooks := NewWebhookDispatcher()
 	return &Engine{
-		AdminToken:  NewAuthToken(AdminTokenLength),
-		ActivityMap: make(map[int]*Activity),
-		TokenMap:    make(map[string]*Activity),
+		AdminToken:     NewAuthToken(AdminTokenLength),
+		ActivityMap:    make(map[int]*Activity),
+		TokenMap:       make(map[string]*Activity),
+		Audit:          NewAuditLog(),
+		Hooks:          hooks,
+		GlobalWebhooks: NewWebhookBoard(0, hooks, nil),
+		clientSecret:   []byte(NewAuthToken(AdminTokenLength)),
 	}
 }
 
-// Engine struct
+// Engine struct; the mutex guards the maps, the id counter and the shutdown state,
+// activities guard their own state. AdminToken is set before serving and never changes
 type Engine struct {
-	AdminToken  string
-	ActivityMap map[int]*Activity
-	TokenMap    map[string]*Activity
-	IdCount     int
-	mutex       sync.Mutex
+	AdminToken     string
+	ActivityMap    map[int]*Activity
+	TokenMap       map[string]*Activity
+	IdCount        int
+	Audit          *AuditLog
+	Hooks          *WebhookDispatcher
+	GlobalWebhooks *WebhookBoard // of all activities
+	mutex          sync.RWMutex
+	closing        bool
+	notice         string
+	clientSecret   []byte
 }
 
-// generate a unique token
+// stop accepting pushes and reactions, and leave a notice for display and review clients
+func (e *Engine) Shutdown(notice string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.closing = true
+	e.notice = notice
+}
+
+// whether the engine is shutting down
+func (e *Engine) Closing() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.closing
+}
+
+// notice for display and review clients, empty normally
+func (e *Engine) Notice() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.notice
+}
+
+// generate a token unused at the time; NewActivityFull checks again when adding
 func (e *Engine) newToken() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
 	for {
 		token := NewAuthToken(ActivityTokenLength)
 		if _, ok := e.TokenMap[token]; !ok {
@@ -79,6 +250,28 @@ func (e *Engine) Login(authToken string) (string, error) {
 	return "", NotAuthorizedError
 }
 
+// client id for a client of comment tokens, the one given if the engine issued it or a new one;
+// ids are signed so that clients cannot make up ids or take another's
+func (e *Engine) ClientId(client string) string {
+	if e.ValidClientId(client) {
+		return client
+	}
+	nonce := NewAuthToken(ClientIdLength)
+	return nonce + "." + e.clientMAC(nonce)
+}
+
+// whether the client id was issued by the engine
+func (e *Engine) ValidClientId(client string) bool {
+	nonce, mac, ok := strings.Cut(client, ".")
+	return ok && len(nonce) == ClientIdLength && hmac.Equal([]byte(mac), []byte(e.clientMAC(nonce)))
+}
+
+func (e *Engine) clientMAC(nonce string) string {
+	m := hmac.New(sha256.New, e.clientSecret)
+	m.Write([]byte(nonce))
+	return hex.EncodeToString(m.Sum(nil))[:32]
+}
+
 // create a activity with name and add it to engine
 func (e *Engine) NewActivity(authToken string, name string) (*Activity, error) {
 	if !IsOneOf(authToken, e.AdminToken) {
@@ -92,54 +285,104 @@ func (e *Engine) NewActivityFull(authToken string, name string, commentToken str
 	if !IsOneOf(authToken, e.AdminToken) {
 		return nil, NotAuthorizedError
 	}
-	if _, ok := e.TokenMap[commentToken]; ok {
+	if commentToken == reviewToken || commentToken == displayToken || reviewToken == displayToken {
 		return nil, AlreadyExistError
 	}
-	if _, ok := e.TokenMap[reviewToken]; ok {
-		return nil, AlreadyExistError
-	}
-	if _, ok := e.TokenMap[displayToken]; ok {
-		return nil, AlreadyExistError
+	moderation, err := NewModerationChain(DefaultModeration)
+	if err != nil {
+		return nil, err
 	}
 
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
+	for _, t := range []string{commentToken, reviewToken, displayToken} {
+		if _, ok := e.TokenMap[t]; ok || t == e.AdminToken {
+			return nil, AlreadyExistError
+		}
+	}
+
 	e.IdCount++
 	id := e.IdCount
 	act := &Activity{
-		BasicActivity: BasicActivity{
-			CommentMap:    make(map[int]*LabelComment),
-			InitialQueue:  make([]*LabelComment, 0, QueueDefaultLength),
-			ApprovedQueue: make([]*LabelComment, 0, QueueDefaultLength),
-		},
 		Id:           id,
-		Name:         name,
 		CommentToken: commentToken,
 		ReviewToken:  reviewToken,
 		DisplayToken: displayToken,
-		ReviewOn:     true,
+		Reactions:    NewReactionBoard(DefaultReactions),
+		Polls:        NewPollBoard(),
+		Questions:    NewQuestionBoard(),
+		PushKeys:     NewPushKeyBoard(),
+		Dedupe:       NewDedupeBoard(),
+		Webhooks:     NewWebhookBoard(id, e.Hooks, e.GlobalWebhooks),
+		Reputation:   NewReputationBoard(),
 	}
+	act.SetRetention(DefaultRetention)
+	act.settings.Store(&ActivitySettings{
+		Name:      name,
+		ReviewOn:  true,
+		Mode:      ActivityModeDanmaku,
+		TextStyle:  DefaultTextStyle,
+		Dedupe:     DedupePolicy{Mode: DedupeOff},
+		Moderation: moderation,
+	})
 
 	e.ActivityMap[id] = act
 	e.TokenMap[act.CommentToken] = act
 	e.TokenMap[act.ReviewToken] = act
 	e.TokenMap[act.DisplayToken] = act
+	e.Audit.Record("admin", id, "NewActivity", nil, name)
+	act.Webhooks.Emit(EventActivityCreated, nil)
 	return act, nil
 }
 
 // get activity by token
 func (e *Engine) ActivityByToken(token string) (*Activity, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
 	act, ok := e.TokenMap[token]
 	return act, ok
 }
 
+// get activity by id
+func (e *Engine) activityById(id int) (*Activity, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	act, ok := e.ActivityMap[id]
+	return act, ok
+}
+
+// activity managed by the token: any activity by id for admin, its own activity for review
+func (e *Engine) managedActivity(authToken string, id int) (*Activity, error) {
+	if IsOneOf(authToken, e.AdminToken) {
+		act, ok := e.activityById(id)
+		if !ok {
+			return nil, NotExistError
+		}
+		return act, nil
+	}
+
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+	if !IsOneOf(authToken, act.ReviewToken) {
+		return nil, NotAuthorizedError
+	}
+	return act, nil
+}
+
 // all activity; action permit: admin
 func (e *Engine) Activities(authToken string) ([]*Activity, error) {
 	if !IsOneOf(authToken, e.AdminToken) {
 		return nil, NotAuthorizedError
 	}
 
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
 	r := make([]*Activity, 0, len(e.ActivityMap))
 	for _, a := range e.ActivityMap {
 		r = append(r, a)
@@ -153,14 +396,20 @@ func (e *Engine) DelActivity(authToken string, id int) (error) {
 		return NotAuthorizedError
 	}
 
+	e.mutex.Lock()
 	act, ok := e.ActivityMap[id]
+	if ok {
+		delete(e.TokenMap, act.CommentToken)
+		delete(e.TokenMap, act.ReviewToken)
+		delete(e.TokenMap, act.DisplayToken)
+		delete(e.ActivityMap, id)
+	}
+	e.mutex.Unlock()
 	if !ok {
 		return NotExistError
 	}
-	delete(e.TokenMap, act.CommentToken)
-	delete(e.TokenMap, act.ReviewToken)
-	delete(e.TokenMap, act.DisplayToken)
-	delete(e.ActivityMap, id)
+	e.Audit.Record("admin", id, "DelActivity", nil, "")
+	act.Webhooks.Emit(EventActivityDeleted, nil)
 	return nil
 }
 
@@ -170,11 +419,12 @@ func (e *Engine) RenameActivity(authToken string, id int, name string) (error) {
 		return NotAuthorizedError
 	}
 
-	act, ok := e.ActivityMap[id]
+	act, ok := e.activityById(id)
 	if !ok {
 		return NotExistError
 	}
-	act.Name = name
+	act.updateSettings(func(s *ActivitySettings) { s.Name = name })
+	e.Audit.Record("admin", id, "RenameActivity", nil, name)
 	return nil
 }
 
@@ -184,11 +434,12 @@ func (e *Engine) ReviewOn(authToken string, id int) (error) {
 		return NotAuthorizedError
 	}
 
-	act, ok := e.ActivityMap[id]
+	act, ok := e.activityById(id)
 	if !ok {
 		return NotExistError
 	}
-	act.ReviewOn = true
+	act.updateSettings(func(s *ActivitySettings) { s.ReviewOn = true })
+	e.Audit.Record("admin", id, "ReviewOn", nil, "")
 	return nil
 }
 
@@ -198,51 +449,366 @@ func (e *Engine) ReviewOff(authToken string, id int) (error) {
 		return NotAuthorizedError
 	}
 
-	act, ok := e.ActivityMap[id]
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	act.updateSettings(func(s *ActivitySettings) { s.ReviewOn = false })
+	e.Audit.Record("admin", id, "ReviewOff", nil, "")
+	return nil
+}
+
+// change part of the text style of an activity, update gets a copy of the current one;
+// action permit: admin
+func (e *Engine) UpdateTextStyle(authToken string, id int, update func(style *TextStyle)) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	valid := false
+	act.updateSettings(func(s *ActivitySettings) {
+		style := *s.TextStyle
+		update(&style)
+		if valid = style.Valid(); valid {
+			s.TextStyle = &style
+		}
+	})
+	if !valid {
+		return IllFormatError
+	}
+	e.Audit.Record("admin", id, "SetTextStyle", nil, "")
+	return nil
+}
+
+// switch between danmaku and Q&A mode; action permit: admin
+func (e *Engine) SetMode(authToken string, id int, mode string) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
 	if !ok {
 		return NotExistError
 	}
-	act.ReviewOn = false
+	if !containsString(ActivityModes, mode) {
+		return IllFormatError
+	}
+	act.updateSettings(func(s *ActivitySettings) { s.Mode = mode })
+	e.Audit.Record("admin", id, "SetMode", nil, mode)
+	return nil
+}
+
+// set origins allowed to embed the activity, replacing the public CORS origins; action permit: admin
+func (e *Engine) SetOrigins(authToken string, id int, origins []string) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	for _, o := range origins {
+		if !ValidOrigin(o) {
+			return IllFormatError
+		}
+	}
+	act.updateSettings(func(s *ActivitySettings) { s.Origins = origins })
+	e.Audit.Record("admin", id, "SetOrigins", nil, strings.Join(origins, " "))
+	return nil
+}
+
+// set allowed reactions; action permit: admin
+func (e *Engine) SetReactions(authToken string, id int, reactions []string) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	if !ValidReactions(reactions) {
+		return IllFormatError
+	}
+	act.Reactions.SetAllowed(reactions)
+	e.Audit.Record("admin", id, "SetReactions", nil, strings.Join(reactions, " "))
+	return nil
+}
+
+// set the retention policy, limited by DefaultRetention; action permit: admin
+func (e *Engine) SetRetention(authToken string, id int, p RetentionPolicy) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	if !p.Valid() {
+		return IllFormatError
+	}
+	p = p.Clamp(DefaultRetention)
+	act.SetRetention(p)
+	e.Audit.Record("admin", id, "SetRetention", nil, fmt.Sprintf("max_comments=%d ttl=%s max_queue_depth=%d overflow=%s", p.MaxComments, p.TTL, p.MaxQueueDepth, p.Overflow))
 	return nil
 }
 
+// set duplicate suppression; action permit: admin
+func (e *Engine) SetDedupe(authToken string, id int, p DedupePolicy) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	if !p.Valid() {
+		return IllFormatError
+	}
+	act.updateSettings(func(s *ActivitySettings) { s.Dedupe = p })
+	e.Audit.Record("admin", id, "SetDedupe", nil, fmt.Sprintf("mode=%s window=%s distance=%d", p.Mode, p.Window, p.Distance))
+	return nil
+}
+
+// replace the moderation chain; action permit: admin
+func (e *Engine) SetModeration(authToken string, id int, configs []StageConfig) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	act, ok := e.activityById(id)
+	if !ok {
+		return NotExistError
+	}
+	chain, err := NewModerationChain(configs)
+	if err != nil {
+		return &json.Error{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	act.updateSettings(func(s *ActivitySettings) { s.Moderation = chain })
+	kinds := make([]string, len(configs))
+	for i, c := range configs {
+		kinds[i] = c.Kind
+	}
+	e.Audit.Record("admin", id, "SetModeration", nil, strings.Join(kinds, ","))
+	return nil
+}
+
+// webhook board of an activity, or of all activities for id 0
+func (e *Engine) webhookBoard(id int) (*WebhookBoard, error) {
+	if id == 0 {
+		return e.GlobalWebhooks, nil
+	}
+	act, ok := e.activityById(id)
+	if !ok {
+		return nil, NotExistError
+	}
+	return act.Webhooks, nil
+}
+
+// subscribe a URL to events of an activity, of all activities for id 0; all events if none,
+// a secret is generated if empty; action permit: admin
+func (e *Engine) AddWebhook(authToken string, id int, url string, secret string, events []string) (*Webhook, error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return nil, NotAuthorizedError
+	}
+
+	b, err := e.webhookBoard(id)
+	if err != nil {
+		return nil, err
+	}
+	h, err := b.Add(url, secret, events)
+	if err != nil {
+		return nil, err
+	}
+	e.Audit.Record("admin", id, "AddWebhook", []int{h.Id}, url)
+	return h, nil
+}
+
+// unsubscribe; action permit: admin
+func (e *Engine) DelWebhook(authToken string, id int, hookId int) (error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return NotAuthorizedError
+	}
+
+	b, err := e.webhookBoard(id)
+	if err != nil {
+		return err
+	}
+	if !b.Remove(hookId) {
+		return NotExistError
+	}
+	e.Audit.Record("admin", id, "DelWebhook", []int{hookId}, "")
+	return nil
+}
+
+// webhooks and their latest deliveries; action permit: admin
+func (e *Engine) Webhooks(authToken string, id int) ([]*Webhook, []WebhookDelivery, error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return nil, nil, NotAuthorizedError
+	}
+
+	b, err := e.webhookBoard(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b.Hooks(), b.History(), nil
+}
+
+// post a ping to a webhook and wait for the outcome; action permit: admin
+func (e *Engine) TestWebhook(authToken string, id int, hookId int) (*WebhookDelivery, error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		return nil, NotAuthorizedError
+	}
+
+	b, err := e.webhookBoard(id)
+	if err != nil {
+		return nil, err
+	}
+	return b.Test(hookId)
+}
+
 // reset; action permit: admin
 func (e *Engine) Reset(authToken string, id int) (error) {
 	if !IsOneOf(authToken, e.AdminToken) {
 		return NotAuthorizedError
 	}
 
-	act, ok := e.ActivityMap[id]
+	act, ok := e.activityById(id)
 	if !ok {
 		return NotExistError
 	}
 	act.Reset()
+	act.Reactions.Reset()
+	act.Polls.Reset()
+	act.Questions.Reset()
+	act.PushKeys.Reset()
+	act.Dedupe.Reset()
+	act.Reputation.Reset()
+	e.Audit.Record("admin", id, "Reset", nil, "")
+	act.Webhooks.Emit(EventActivityReset, nil)
 	return nil
 }
 
 // push a comment; action permit: comment, review, display
 func (e *Engine) Push(authToken string, tp string, attr map[string]string) (*LabelComment, error) {
-	act, ok := e.ActivityByToken(authToken)
+	return e.PushKeyed(authToken, "", tp, attr)
+}
+
+// push a comment with a client key, empty for none; pushing again with a key pushed within
+// the window returns the original comment instead of a new one; action permit: comment, review, display
+func (e *Engine) PushKeyed(authToken string, key string, tp string, attr map[string]string) (*LabelComment, error) {
+	return e.PushFrom(&Sender{Token: authToken, Key: key}, tp, attr)
+}
+
+// push a comment on behalf of a sender, see PushKeyed; client ids the engine did not issue
+// are ignored; action permit: comment, review, display
+func (e *Engine) PushFrom(s *Sender, tp string, attr map[string]string) (*LabelComment, error) {
+	if s.Client != "" && !e.ValidClientId(s.Client) {
+		unknown := *s
+		unknown.Client = ""
+		s = &unknown
+	}
+	lc, err := e.push(s, tp, attr)
+	if err != nil {
+		observePushRejected(err)
+	}
+	return lc, err
+}
+
+func (e *Engine) push(s *Sender, tp string, attr map[string]string) (*LabelComment, error) {
+	if e.Closing() {
+		return nil, ShuttingDownError
+	}
+
+	act, ok := e.ActivityByToken(s.Token)
 	if !ok {
 		return nil, NotExistError
 	}
 
-	if !IsOneOf(authToken, act.CommentToken, act.ReviewToken, act.DisplayToken) {
+	if !IsOneOf(s.Token, act.CommentToken, act.ReviewToken, act.DisplayToken) {
 		return nil, NotAuthorizedError
 	}
 
-	c, ok := NewComment(tp, attr)
+	if len(s.Key) > PushKeyMaxLength {
+		return nil, BadPushKeyError
+	}
+
+	if s.Key == "" {
+		return act.add(s, tp, attr)
+	}
+	lc, _, err := act.PushKeys.Do(s.id(), s.Key, func() (*LabelComment, error) {
+		return act.add(s, tp, attr)
+	})
+	return lc, err
+}
+
+// comment of a batch push
+type PushItem struct {
+	Key  string
+	Type string
+	Attr map[string]string
+}
+
+// push comments in one call, in order; the token is checked once for the whole batch,
+// then each comment succeeds or fails on its own with the comment or error at its index
+func (e *Engine) PushBatch(authToken string, items []PushItem) ([]*LabelComment, []error, error) {
+	return e.PushBatchFrom(&Sender{Token: authToken}, items)
+}
+
+// push comments in one call on behalf of a sender, see PushBatch; item keys replace the sender key
+func (e *Engine) PushBatchFrom(s *Sender, items []PushItem) ([]*LabelComment, []error, error) {
+	authToken := s.Token
+	if e.Closing() {
+		return nil, nil, ShuttingDownError
+	}
+
+	act, ok := e.ActivityByToken(authToken)
 	if !ok {
-		return nil, IllFormatError
+		return nil, nil, NotExistError
 	}
 
-	lc := act.Add(c)
+	if !IsOneOf(authToken, act.CommentToken, act.ReviewToken, act.DisplayToken) {
+		return nil, nil, NotAuthorizedError
+	}
 
-	if !act.ReviewOn {
-		act.Approve(act.Review())
+	if len(items) > BatchMaxLength {
+		return nil, nil, BatchTooLongError
 	}
 
-	return lc, nil
+	lcs := make([]*LabelComment, len(items))
+	errs := make([]error, len(items))
+	for i, item := range items {
+		lcs[i], errs[i] = e.PushFrom(&Sender{Token: authToken, Key: item.Key, Client: s.Client, Addr: s.Addr}, item.Type, item.Attr)
+	}
+	return lcs, errs, nil
+}
+
+// react with an emoji, bypassing review; action permit: comment, review, display
+func (e *Engine) React(authToken string, emoji string, count int) (error) {
+	if e.Closing() {
+		return ShuttingDownError
+	}
+
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.CommentToken, act.ReviewToken, act.DisplayToken) {
+		return NotAuthorizedError
+	}
+
+	if !act.Reactions.React(emoji, count) {
+		return BadReactionError
+	}
+	return nil
 }
 
 // review; action permit: review
@@ -259,6 +825,109 @@ func (e *Engine) Review(authToken string) ([]*LabelComment, error) {
 	return act.Review(), nil
 }
 
+// orders of comments to review
+const (
+	ReviewOldestFirst = "oldest"
+	ReviewNewestFirst = "newest"
+	// senders with the most comments approved first
+	ReviewTrustedFirst = "trusted"
+	// senders with the most comments denied first
+	ReviewSuspectFirst = "suspect"
+	// grouped by type, in the order of Types if given
+	ReviewByType = "type"
+)
+
+var ReviewOrders = []string{ReviewOldestFirst, ReviewNewestFirst, ReviewTrustedFirst, ReviewSuspectFirst, ReviewByType}
+
+// how a reviewer gets comments: only of Types if any, in Order, oldest first if empty,
+// flagged by moderation first if FlaggedFirst, at most Limit if positive. Comments left
+// out stay for other reviewers, so that a team can split the work
+type ReviewOptions struct {
+	Order        string
+	FlaggedFirst bool
+	Types        []string
+	Limit        int
+}
+
+func (o *ReviewOptions) Valid() bool {
+	return (o.Order == "" || containsString(ReviewOrders, o.Order)) && o.Limit >= 0
+}
+
+// comparison of comments in the order of the options, nil for arrival order
+func (o *ReviewOptions) less(act *Activity) func(a, b *LabelComment) bool {
+	var order func(a, b *LabelComment) bool
+	switch o.Order {
+	case ReviewNewestFirst:
+		order = func(a, b *LabelComment) bool { return a.Id > b.Id }
+	case ReviewTrustedFirst:
+		order = func(a, b *LabelComment) bool { return act.Reputation.Score(a.Sender) > act.Reputation.Score(b.Sender) }
+	case ReviewSuspectFirst:
+		order = func(a, b *LabelComment) bool { return act.Reputation.Score(a.Sender) < act.Reputation.Score(b.Sender) }
+	case ReviewByType:
+		rank := func(c *LabelComment) int {
+			for i, tp := range o.Types {
+				if c.Type == tp {
+					return i
+				}
+			}
+			return len(o.Types)
+		}
+		order = func(a, b *LabelComment) bool {
+			if ra, rb := rank(a), rank(b); ra != rb {
+				return ra < rb
+			}
+			return a.Type < b.Type
+		}
+	}
+	if !o.FlaggedFirst {
+		return order
+	}
+	return func(a, b *LabelComment) bool {
+		if fa, fb := a.Flagged(), b.Flagged(); fa != fb {
+			return fa
+		}
+		return order != nil && order(a, b)
+	}
+}
+
+// review with options, see ReviewOptions; action permit: review
+func (e *Engine) ReviewWith(authToken string, o *ReviewOptions) ([]*LabelComment, error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+
+	if !IsOneOf(authToken, act.ReviewToken) {
+		return nil, NotAuthorizedError
+	}
+
+	if !o.Valid() {
+		return nil, IllFormatError
+	}
+
+	var match func(c *LabelComment) bool
+	if len(o.Types) > 0 {
+		match = func(c *LabelComment) bool { return containsString(o.Types, c.Type) }
+	}
+	return act.ReviewSelect(match, o.less(act), o.Limit), nil
+}
+
+// review with options and hand the comments to send, putting them back for the next review
+// if it fails; action permit: review
+func (e *Engine) ReviewTo(authToken string, o *ReviewOptions, send func(lcs []*LabelComment) error) (error) {
+	lcs, err := e.ReviewWith(authToken, o)
+	if err != nil {
+		return err
+	}
+
+	if err := send(lcs); err != nil {
+		act, _ := e.ActivityByToken(authToken)
+		act.Unreview(lcs)
+		return err
+	}
+	return nil
+}
+
 // approve; action permit: review
 func (e *Engine) Approve(authToken string, ids []int) (error) {
 	act, ok := e.ActivityByToken(authToken)
@@ -271,7 +940,8 @@ func (e *Engine) Approve(authToken string, ids []int) (error) {
 	}
 
 	lcs := act.Fetch(ids)
-	act.Approve(lcs)
+	act.approve(lcs)
+	e.Audit.Record("review", act.Id, "Approve", ids, "")
 	return nil
 }
 
@@ -288,6 +958,54 @@ func (e *Engine) Deny(authToken string, ids []int) (error) {
 
 	lcs := act.Fetch(ids)
 	act.Deny(lcs)
+	act.decided(nil, lcs)
+	e.Audit.Record("review", act.Id, "Deny", ids, "")
+	return nil
+}
+
+// approve some comments and deny others in one step; an id in both lists is ill format;
+// action permit: review
+func (e *Engine) Decide(authToken string, approveIds []int, denyIds []int) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.ReviewToken) {
+		return NotAuthorizedError
+	}
+
+	for _, id := range approveIds {
+		if containsInt(denyIds, id) {
+			return IllFormatError
+		}
+	}
+
+	act.decide(act.Fetch(approveIds), act.Fetch(denyIds))
+	if len(approveIds) > 0 {
+		e.Audit.Record("review", act.Id, "Approve", approveIds, "")
+	}
+	if len(denyIds) > 0 {
+		e.Audit.Record("review", act.Id, "Deny", denyIds, "")
+	}
+	return nil
+}
+
+// retract approved or displayed comments; action permit: review
+func (e *Engine) Retract(authToken string, ids []int) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.ReviewToken) {
+		return NotAuthorizedError
+	}
+
+	lcs := act.Fetch(ids)
+	act.Retract(lcs)
+	act.Questions.Remove(ids)
+	e.Audit.Record("review", act.Id, "Retract", ids, "")
 	return nil
 }
 
@@ -302,5 +1020,186 @@ func (e *Engine) Display(authToken string) ([]*LabelComment, error) {
 		return nil, NotAuthorizedError
 	}
 
-	return act.Display(), nil
+	r := act.Display()
+	if len(r) > 0 {
+		act.Webhooks.Emit(EventCommentDisplayed, r)
+	}
+	return r, nil
+}
+
+// display and hand the comments with the retractions to send, putting them back for the
+// next display if it fails; action permit: display
+func (e *Engine) DisplayTo(authToken string, send func(lcs []*LabelComment, retracted []*LabelComment) error) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.DisplayToken) {
+		return NotAuthorizedError
+	}
+
+	r := act.Display()
+	rcs := act.Retractions()
+	if err := send(r, rcs); err != nil {
+		act.Undisplay(r, rcs)
+		return err
+	}
+	if len(r) > 0 {
+		act.Webhooks.Emit(EventCommentDisplayed, r)
+	}
+	return nil
+}
+
+// retracted comments to be removed from screen; action permit: display
+func (e *Engine) Retractions(authToken string) ([]*LabelComment, error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+
+	if !IsOneOf(authToken, act.DisplayToken) {
+		return nil, NotAuthorizedError
+	}
+
+	return act.Retractions(), nil
+}
+
+// aggregated reaction bursts; action permit: display
+func (e *Engine) Reactions(authToken string) ([]*ReactionBurst, error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+
+	if !IsOneOf(authToken, act.DisplayToken) {
+		return nil, NotAuthorizedError
+	}
+
+	return act.Reactions.Flush(), nil
+}
+
+// create a closed poll, id is ignored for review; action permit: admin, review
+func (e *Engine) NewPoll(authToken string, id int, question string, options []string) (*Poll, error) {
+	act, err := e.managedActivity(authToken, id)
+	if err != nil {
+		return nil, err
+	}
+
+	question, options, ok := NormalizePoll(question, options)
+	if !ok {
+		return nil, IllFormatError
+	}
+	p := act.Polls.Add(question, options)
+	role, _ := e.Login(authToken)
+	e.Audit.Record(role, act.Id, "NewPoll", []int{p.Id}, question)
+	return p, nil
+}
+
+// open or close a poll, id is ignored for review; action permit: admin, review
+func (e *Engine) SetPollOpen(authToken string, id int, pollId int, open bool) (error) {
+	act, err := e.managedActivity(authToken, id)
+	if err != nil {
+		return err
+	}
+
+	if !act.Polls.SetOpen(pollId, open) {
+		return NotExistError
+	}
+	role, _ := e.Login(authToken)
+	e.Audit.Record(role, act.Id, "SetPollOpen", []int{pollId}, strconv.FormatBool(open))
+	return nil
+}
+
+// vote for an option of an open poll, once per client id; action permit: comment
+func (e *Engine) Vote(authToken string, pollId int, client string, option int) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.CommentToken) {
+		return NotAuthorizedError
+	}
+	if !e.ValidClientId(client) {
+		return InvalidClientError
+	}
+
+	return act.Polls.Vote(pollId, client, option)
+}
+
+// polls with live tallies; action permit: comment, review, display
+func (e *Engine) Polls(authToken string) ([]*Poll, error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+
+	if !IsOneOf(authToken, act.CommentToken, act.ReviewToken, act.DisplayToken) {
+		return nil, NotAuthorizedError
+	}
+
+	return act.Polls.Polls(), nil
+}
+
+// upvote a question, once per client id; action permit: comment
+func (e *Engine) Upvote(authToken string, id int, client string) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.CommentToken) {
+		return NotAuthorizedError
+	}
+	if !e.ValidClientId(client) {
+		return InvalidClientError
+	}
+
+	return act.Questions.Upvote(id, client)
+}
+
+// mark a question open, answering or answered; action permit: review, display
+func (e *Engine) MarkQuestion(authToken string, id int, state string) (error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return NotExistError
+	}
+
+	if !IsOneOf(authToken, act.ReviewToken, act.DisplayToken) {
+		return NotAuthorizedError
+	}
+
+	if err := act.Questions.Mark(id, state); err != nil {
+		return err
+	}
+	role, _ := e.Login(authToken)
+	e.Audit.Record(role, act.Id, "MarkQuestion", []int{id}, state)
+	return nil
+}
+
+// ranked questions; action permit: comment, review, display
+func (e *Engine) Questions(authToken string) ([]*Question, error) {
+	act, ok := e.ActivityByToken(authToken)
+	if !ok {
+		return nil, NotExistError
+	}
+
+	if !IsOneOf(authToken, act.CommentToken, act.ReviewToken, act.DisplayToken) {
+		return nil, NotAuthorizedError
+	}
+
+	return act.Questions.Ranked(), nil
+}
+
+// audit entries matching the filter, review only sees its own activity; action permit: admin, review
+func (e *Engine) AuditLog(authToken string, f *AuditFilter) ([]*AuditEntry, error) {
+	if !IsOneOf(authToken, e.AdminToken) {
+		act, err := e.managedActivity(authToken, 0)
+		if err != nil {
+			return nil, err
+		}
+		f.ActivityId = act.Id
+	}
+	return e.Audit.Query(f), nil
 }
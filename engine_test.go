@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,7 +49,7 @@ func TestEngine_Admin(t *testing.T) {
 
 	err = e.RenameActivity(e.AdminToken, act2.Id, "Hello")
 	assert.Nil(t, err)
-	assert.Equal(t, "Hello", act2.Name)
+	assert.Equal(t, "Hello", act2.Settings().Name)
 }
 
 func TestEngine_Activity(t *testing.T) {
@@ -60,7 +64,7 @@ func TestEngine_Activity(t *testing.T) {
 	assert.Equal(t, "Hello", lc.Content)
 	assert.Equal(t, map[string]string{"color": "red"}, lc.Attributes)
 	assert.Equal(t, CommentStatusInitial, lc.Status)
-	assert.Equal(t, act.TotalCount, lc.Id)
+	assert.Equal(t, act.Stats().TotalCount, lc.Id)
 
 	rcs, err := e.Review(act.ReviewToken)
 	assert.Nil(t, err)
@@ -73,3 +77,695 @@ func TestEngine_Activity(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, len(dcs))
 }
+
+func TestEngine_Retract(t *testing.T) {
+	e := NewEngine()
+	act, err := e.NewActivity(e.AdminToken, "Hello")
+	assert.Nil(t, err)
+
+	lc1, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	lc2, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "World", "color": "red"})
+	e.Review(act.ReviewToken)
+	e.Approve(act.ReviewToken, []int{lc1.Id, lc2.Id})
+
+	err = e.Retract(act.DisplayToken, []int{lc1.Id})
+	assert.Equal(t, NotAuthorizedError, err)
+	err = e.Retract(act.ReviewToken, []int{lc1.Id})
+	assert.Nil(t, err)
+
+	dcs, err := e.Display(act.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dcs))
+	assert.Equal(t, lc2.Id, dcs[0].Id)
+
+	err = e.Retract(act.ReviewToken, []int{lc2.Id})
+	assert.Nil(t, err)
+
+	_, err = e.Retractions(act.ReviewToken)
+	assert.Equal(t, NotAuthorizedError, err)
+	rcs, err := e.Retractions(act.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rcs))
+	assert.Equal(t, lc2.Id, rcs[0].Id)
+	assert.Equal(t, 2, act.RetractedCount)
+}
+
+func TestEngine_UpdateTextStyle(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+
+	set := func(token string, style TextStyle) error {
+		return e.UpdateTextStyle(token, act.Id, func(st *TextStyle) { *st = style })
+	}
+
+	assert.Equal(t, NotAuthorizedError, set(act.ReviewToken, TextStyle{}))
+	assert.Equal(t, NotExistError, e.UpdateTextStyle(e.AdminToken, act.Id+1, func(*TextStyle) {}))
+	assert.Equal(t, IllFormatError, set(e.AdminToken, TextStyle{DefaultSize: "huge"}))
+	assert.Equal(t, IllFormatError, set(e.AdminToken, TextStyle{MinLength: -1}))
+	// invalid updates leave the style alone
+	assert.Equal(t, DefaultTextStyle, act.Settings().TextStyle)
+	err := set(e.AdminToken, TextStyle{DefaultColor: "red", Sizes: []string{TextSizeSmall}})
+	assert.Nil(t, err)
+
+	lc, err := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "size": TextSizeSmall})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"color": "red", "size": TextSizeSmall}, lc.Attributes)
+
+	_, err = e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "size": TextSizeLarge})
+	assert.Equal(t, BadSizeError, err)
+	_, err = e.Push(act.CommentToken, "text", map[string]string{"text": "Hello"})
+	assert.Equal(t, BadSizeError, err)
+}
+
+func TestDanmakuService_SetTextStyle(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	type args = struct {
+		Token        string
+		Id           int
+		DefaultColor *string
+		DefaultMode  *string
+		DefaultSize  *string
+		Colors       []string
+		Modes        []string
+		Sizes        []string
+		MinLength    *int
+		MaxLength    *int
+	}
+	red, zero, two := "red", 0, 2
+
+	// omitted fields keep their value, lengths included
+	assert.Nil(t, s.SetTextStyle(&Context{}, &args{Token: e.AdminToken, Id: act.Id, DefaultColor: &red}, &struct{}{}))
+	assert.Equal(t, &TextStyle{DefaultColor: "red", MinLength: 1, MaxLength: TextDefaultMaxLength}, act.Settings().TextStyle)
+	assert.Equal(t, IllFormatError, s.SetTextStyle(&Context{}, &args{Token: e.AdminToken, Id: act.Id, MinLength: &two, MaxLength: &two, Sizes: []string{"huge"}}, &struct{}{}))
+	assert.Equal(t, TextDefaultMaxLength, act.Settings().TextStyle.MaxLength)
+
+	// the limit is lifted only when asked for
+	assert.Nil(t, s.SetTextStyle(&Context{}, &args{Token: e.AdminToken, Id: act.Id, MaxLength: &zero}, &struct{}{}))
+	assert.Equal(t, &TextStyle{DefaultColor: "red", MinLength: 1}, act.Settings().TextStyle)
+	assert.Equal(t, &TextStyle{MinLength: 1, MaxLength: TextDefaultMaxLength}, DefaultTextStyle)
+}
+
+func TestEngine_React(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+
+	err := e.SetReactions(e.AdminToken, act.Id, []string{"👏👏"})
+	assert.Equal(t, IllFormatError, err)
+	err = e.SetReactions(e.AdminToken, act.Id, []string{"👏"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, e.React(act.CommentToken, "👏", 2))
+	assert.Equal(t, BadReactionError, e.React(act.CommentToken, "❤️", 1))
+	assert.Equal(t, NotExistError, e.React("", "👏", 1))
+	assert.Equal(t, 0, act.Stats().InitialDepth)
+
+	_, err = e.Reactions(act.CommentToken)
+	assert.Equal(t, NotAuthorizedError, err)
+	act.Reactions.now = func() time.Time { return time.Now().Add(act.Reactions.Window) }
+	bs, err := e.Reactions(act.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(bs))
+	assert.Equal(t, map[string]int{"👏": 2}, bs[0].Counts)
+
+	e.Reset(e.AdminToken, act.Id)
+	assert.Equal(t, 0, act.Reactions.TotalCount)
+}
+
+func TestEngine_ClientId(t *testing.T) {
+	e := NewEngine()
+	id := e.ClientId("")
+	assert.True(t, e.ValidClientId(id))
+	assert.Equal(t, id, e.ClientId(id))
+	assert.NotEqual(t, id, e.ClientId(""))
+
+	// made up, altered or issued by another engine
+	for _, forged := range []string{"", "abc", id[:ClientIdLength] + ".", id[:ClientIdLength-1] + "g" + id[ClientIdLength:], NewEngine().ClientId("")} {
+		assert.False(t, e.ValidClientId(forged), forged)
+		assert.NotEqual(t, forged, e.ClientId(forged))
+	}
+}
+
+func TestDanmakuService_Login(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	login := func(token string, client string) (string, string) {
+		reply := &struct {
+			Type   string `json:"type"`
+			Client string `json:"client,omitempty"`
+		}{}
+		s.Login(&Context{}, &struct {
+			Token  string
+			Client string
+		}{token, client}, reply)
+		return reply.Type, reply.Client
+	}
+
+	// only comment tokens get a client id, kept across logins
+	tp, client := login(act.DisplayToken, "")
+	assert.Equal(t, "display", tp)
+	assert.Equal(t, "", client)
+	tp, client = login(act.CommentToken, "")
+	assert.Equal(t, "comment", tp)
+	assert.True(t, e.ValidClientId(client))
+	_, again := login(act.CommentToken, client)
+	assert.Equal(t, client, again)
+	_, other := login(act.CommentToken, "forged")
+	assert.NotEqual(t, "forged", other)
+	assert.True(t, e.ValidClientId(other))
+}
+
+func TestEngine_Poll(t *testing.T) {
+	e := NewEngine()
+	act1, _ := e.NewActivity(e.AdminToken, "First")
+	act2, _ := e.NewActivity(e.AdminToken, "Second")
+
+	_, err := e.NewPoll(act1.CommentToken, act1.Id, "q", []string{"a", "b"})
+	assert.Equal(t, NotAuthorizedError, err)
+	_, err = e.NewPoll(e.AdminToken, 100, "q", []string{"a", "b"})
+	assert.Equal(t, NotExistError, err)
+	_, err = e.NewPoll(e.AdminToken, act1.Id, "q", []string{"a"})
+	assert.Equal(t, IllFormatError, err)
+
+	p, err := e.NewPoll(e.AdminToken, act1.Id, "q", []string{"a", "b"})
+	assert.Nil(t, err)
+	// review token manages its own activity regardless of id
+	p2, err := e.NewPoll(act2.ReviewToken, act1.Id, "q", []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(act1.Polls.PollMap))
+	assert.Equal(t, 1, len(act2.Polls.PollMap))
+
+	assert.Nil(t, e.SetPollOpen(act1.ReviewToken, 0, p.Id, true))
+	assert.Equal(t, NotExistError, e.SetPollOpen(act1.ReviewToken, 0, p2.Id+1, true))
+
+	v1, v2 := e.ClientId(""), e.ClientId("")
+	assert.Nil(t, e.Vote(act1.CommentToken, p.Id, v1, 1))
+	assert.Equal(t, AlreadyVotedError, e.Vote(act1.CommentToken, p.Id, v1, 1))
+	assert.Equal(t, NotAuthorizedError, e.Vote(act1.DisplayToken, p.Id, v2, 1))
+	assert.Equal(t, InvalidClientError, e.Vote(act1.CommentToken, p.Id, "v2", 1))
+	assert.Equal(t, InvalidClientError, e.Vote(act1.CommentToken, p.Id, "", 1))
+
+	_, err = e.Polls(e.AdminToken)
+	assert.Equal(t, NotExistError, err)
+	ps, err := e.Polls(act1.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1}, ps[0].Tally)
+}
+
+func TestEngine_QA(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+
+	assert.Equal(t, IllFormatError, e.SetMode(e.AdminToken, act.Id, "karaoke"))
+	assert.Nil(t, e.SetMode(e.AdminToken, act.Id, ActivityModeQA))
+
+	lc1, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Why?", "color": "red"})
+	lc2, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "How?", "color": "red"})
+	e.Review(act.ReviewToken)
+
+	// pending questions are not ranked yet
+	qs, _ := e.Questions(act.CommentToken)
+	assert.Equal(t, 0, len(qs))
+	v1, v2 := e.ClientId(""), e.ClientId("")
+	assert.Equal(t, NotExistError, e.Upvote(act.CommentToken, lc1.Id, v1))
+
+	assert.Nil(t, e.Approve(act.ReviewToken, []int{lc1.Id, lc2.Id}))
+	assert.Equal(t, 2, act.ApprovedCount)
+	dcs, _ := e.Display(act.DisplayToken)
+	assert.Equal(t, 0, len(dcs))
+
+	assert.Nil(t, e.Upvote(act.CommentToken, lc2.Id, v1))
+	assert.Equal(t, NotAuthorizedError, e.Upvote(act.DisplayToken, lc2.Id, v2))
+	assert.Equal(t, InvalidClientError, e.Upvote(act.CommentToken, lc2.Id, "v2"))
+	qs, err := e.Questions(act.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(qs))
+	assert.Equal(t, lc2.Id, qs[0].Comment.Id)
+	assert.Equal(t, 1, qs[0].Votes)
+
+	assert.Equal(t, NotAuthorizedError, e.MarkQuestion(act.CommentToken, lc1.Id, QuestionStateAnswering))
+	assert.Nil(t, e.MarkQuestion(act.DisplayToken, lc1.Id, QuestionStateAnswering))
+	qs, _ = e.Questions(act.ReviewToken)
+	assert.Equal(t, lc1.Id, qs[0].Comment.Id)
+
+	assert.Nil(t, e.Retract(act.ReviewToken, []int{lc1.Id}))
+	qs, _ = e.Questions(act.ReviewToken)
+	assert.Equal(t, 1, len(qs))
+	assert.Equal(t, CommentStatusRetracted, lc1.Status)
+
+	// without review questions are ranked right away
+	e.ReviewOff(e.AdminToken, act.Id)
+	e.Push(act.CommentToken, "text", map[string]string{"text": "When?", "color": "red"})
+	qs, _ = e.Questions(act.ReviewToken)
+	assert.Equal(t, 2, len(qs))
+}
+
+func TestEngine_AuditLog(t *testing.T) {
+	e := NewEngine()
+	act1, _ := e.NewActivity(e.AdminToken, "First")
+	act2, _ := e.NewActivity(e.AdminToken, "Second")
+	lc, _ := e.Push(act1.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	e.Review(act1.ReviewToken)
+	e.Approve(act1.ReviewToken, []int{lc.Id})
+	e.ReviewOff(e.AdminToken, act2.Id)
+	e.Reset(e.AdminToken, act1.Id)
+	e.DelActivity(e.AdminToken, act2.Id)
+	e.DelActivity(e.AdminToken, act2.Id)
+
+	ens, err := e.AuditLog(e.AdminToken, &AuditFilter{})
+	assert.Nil(t, err)
+	var actions []string
+	for _, en := range ens {
+		actions = append(actions, en.Action)
+	}
+	assert.Equal(t, []string{"NewActivity", "NewActivity", "Approve", "ReviewOff", "Reset", "DelActivity"}, actions)
+	assert.Equal(t, "review", ens[2].Role)
+	assert.Equal(t, []int{lc.Id}, ens[2].Ids)
+
+	// review only sees its own activity
+	ens, err = e.AuditLog(act1.ReviewToken, &AuditFilter{ActivityId: act2.Id})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(ens))
+	_, err = e.AuditLog(act1.CommentToken, &AuditFilter{})
+	assert.Equal(t, NotAuthorizedError, err)
+}
+
+func TestEngine_Shutdown(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	assert.False(t, e.Closing())
+	assert.Equal(t, "", e.Notice())
+
+	lc, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	e.Shutdown("server restarting")
+	assert.True(t, e.Closing())
+	assert.Equal(t, "server restarting", e.Notice())
+
+	_, err := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	assert.Equal(t, ShuttingDownError, err)
+	assert.Equal(t, ShuttingDownError, e.React(act.CommentToken, DefaultReactions[0], 1))
+
+	// reviewing and displaying what is already queued still works
+	rcs, err := e.Review(act.ReviewToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(rcs))
+	assert.Nil(t, e.Approve(act.ReviewToken, []int{lc.Id}))
+	dcs, err := e.Display(act.DisplayToken)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dcs))
+}
+
+// run with -race; admin changes, pushes, reviews and displays on shared activities at once
+func TestEngine_Concurrent(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	const pushers, pushes = 8, 200
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	var displayed []*LabelComment
+
+	for i := 0; i < pushers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < pushes; j++ {
+				_, err := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+				assert.Nil(t, err)
+				e.React(act.CommentToken, DefaultReactions[0], 1)
+			}
+		}()
+	}
+
+	// reviewer, display and admin loop until the pushers are done
+	var loops sync.WaitGroup
+	loop := func(f func(i int)) {
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+					f(i)
+				}
+			}
+		}()
+	}
+	loop(func(int) {
+		lcs, _ := e.Review(act.ReviewToken)
+		ids := make([]int, 0, len(lcs))
+		for _, lc := range lcs {
+			ids = append(ids, lc.Id)
+		}
+		e.Approve(act.ReviewToken, ids)
+	})
+	loop(func(int) {
+		lcs, _ := e.Display(act.DisplayToken)
+		displayed = append(displayed, lcs...)
+		e.Reactions(act.DisplayToken)
+	})
+	loop(func(i int) {
+		if i%2 == 0 {
+			e.ReviewOff(e.AdminToken, act.Id)
+		} else {
+			e.ReviewOn(e.AdminToken, act.Id)
+		}
+		e.RenameActivity(e.AdminToken, act.Id, fmt.Sprintf("Hello %d", i))
+		e.UpdateTextStyle(e.AdminToken, act.Id, func(st *TextStyle) { st.MaxLength = 100 + i%2 })
+		e.SetOrigins(e.AdminToken, act.Id, []string{"https://example.com"})
+		e.SetReactions(e.AdminToken, act.Id, DefaultReactions)
+	})
+	loop(func(i int) {
+		other, err := e.NewActivity(e.AdminToken, "Other")
+		assert.Nil(t, err)
+		e.SetMode(e.AdminToken, other.Id, ActivityModeQA)
+		e.Push(other.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+		if i%2 == 0 {
+			assert.Nil(t, e.DelActivity(e.AdminToken, other.Id))
+		}
+	})
+	loop(func(int) {
+		acts, _ := e.Activities(e.AdminToken)
+		for _, a := range acts {
+			FlattenActivity(a)
+			FlattenActivityDigest(a)
+			e.Login(a.DisplayToken)
+			e.ActivityByToken(a.CommentToken)
+		}
+	})
+
+	wg.Wait()
+	close(done)
+	loops.Wait()
+
+	// drain what is left with review on, so every comment is reviewed once
+	e.ReviewOn(e.AdminToken, act.Id)
+	lcs, _ := e.Review(act.ReviewToken)
+	ids := make([]int, 0, len(lcs))
+	for _, lc := range lcs {
+		ids = append(ids, lc.Id)
+	}
+	e.Approve(act.ReviewToken, ids)
+	last, _ := e.Display(act.DisplayToken)
+	seen := make(map[int]bool)
+	for _, lc := range append(displayed, last...) {
+		assert.False(t, seen[lc.Id], "displayed twice")
+		seen[lc.Id] = true
+	}
+
+	st := act.Stats()
+	assert.Equal(t, pushers*pushes, st.TotalCount)
+	assert.Equal(t, pushers*pushes, st.ApprovedCount)
+	assert.Equal(t, pushers*pushes, st.DisplayedCount)
+	assert.Equal(t, pushers*pushes, len(seen))
+	assert.Equal(t, 0, st.PendingDepth)
+	assert.Equal(t, pushers*pushes, act.Reactions.Count())
+}
+
+func TestEngine_SetRetention(t *testing.T) {
+	defer func(p RetentionPolicy) { DefaultRetention = p }(DefaultRetention)
+	DefaultRetention = RetentionPolicy{MaxComments: 100, TTL: time.Hour, MaxQueueDepth: 10, Overflow: OverflowDropOldest}
+
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	assert.Equal(t, DefaultRetention, act.Retention())
+
+	assert.Equal(t, NotAuthorizedError, e.SetRetention(act.ReviewToken, act.Id, RetentionPolicy{Overflow: OverflowReject}))
+	assert.Equal(t, IllFormatError, e.SetRetention(e.AdminToken, act.Id, RetentionPolicy{Overflow: "ignore"}))
+
+	// limits can only be tightened
+	assert.Nil(t, e.SetRetention(e.AdminToken, act.Id, RetentionPolicy{MaxQueueDepth: 1, Overflow: OverflowReject}))
+	assert.Equal(t, RetentionPolicy{MaxComments: 100, TTL: time.Hour, MaxQueueDepth: 1, Overflow: OverflowReject}, act.Retention())
+
+	_, err := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	assert.Nil(t, err)
+	_, err = e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	assert.Equal(t, QueueFullError, err)
+}
+
+func TestEngine_PushBatch(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+
+	_, _, err := e.PushBatch(e.AdminToken, []PushItem{{Type: "text"}})
+	assert.Equal(t, NotExistError, err)
+	_, _, err = e.PushBatch(act.CommentToken, make([]PushItem, BatchMaxLength+1))
+	assert.Equal(t, BatchTooLongError, err)
+
+	lcs, errs, err := e.PushBatch(act.CommentToken, []PushItem{
+		{Type: "text", Attr: map[string]string{"text": "Hello", "color": "red"}},
+		{Type: "picture", Attr: map[string]string{}},
+		{Type: "text", Attr: map[string]string{"text": "World", "color": "red"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello", lcs[0].Content)
+	assert.Nil(t, lcs[1])
+	assert.Equal(t, UnknownTypeError, errs[1])
+	assert.Equal(t, "World", lcs[2].Content)
+	assert.Equal(t, []error{nil, UnknownTypeError, nil}, errs)
+	assert.Equal(t, 2, act.Stats().InitialDepth)
+}
+
+func TestEngine_Decide(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.ReviewOn(e.AdminToken, act.Id)
+	lcs, _, _ := e.PushBatch(act.CommentToken, []PushItem{
+		{Type: "text", Attr: map[string]string{"text": "1", "color": "red"}},
+		{Type: "text", Attr: map[string]string{"text": "2", "color": "red"}},
+		{Type: "text", Attr: map[string]string{"text": "3", "color": "red"}},
+	})
+	e.Review(act.ReviewToken)
+
+	assert.Equal(t, NotAuthorizedError, e.Decide(act.CommentToken, []int{lcs[0].Id}, nil))
+	assert.Equal(t, IllFormatError, e.Decide(act.ReviewToken, []int{lcs[0].Id}, []int{lcs[0].Id}))
+	assert.Equal(t, CommentStatusPending, lcs[0].Status)
+
+	assert.Nil(t, e.Decide(act.ReviewToken, []int{lcs[0].Id, lcs[2].Id}, []int{lcs[1].Id}))
+	assert.Equal(t, CommentStatusDenied, lcs[1].Status)
+	st := act.Stats()
+	assert.Equal(t, 0, st.PendingDepth)
+	assert.Equal(t, 2, st.ApprovedCount)
+	assert.Equal(t, 1, st.DeniedCount)
+	dcs, _ := e.Display(act.DisplayToken)
+	assert.Equal(t, []*LabelComment{lcs[0], lcs[2]}, dcs)
+
+	entries, _ := e.AuditLog(e.AdminToken, &AuditFilter{ActivityId: act.Id})
+	assert.Equal(t, "Deny", entries[len(entries)-1].Action)
+	assert.Equal(t, "Approve", entries[len(entries)-2].Action)
+}
+
+func TestEngine_PushKeyed(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	attr := map[string]string{"text": "Hello", "color": "red"}
+
+	lc, err := e.PushKeyed(act.CommentToken, "retry-1", "text", attr)
+	assert.Nil(t, err)
+	again, err := e.PushKeyed(act.CommentToken, "retry-1", "text", attr)
+	assert.Nil(t, err)
+	assert.Same(t, lc, again)
+	other, _ := e.PushKeyed(act.CommentToken, "retry-2", "text", attr)
+	assert.NotEqual(t, lc.Id, other.Id)
+	e.Push(act.CommentToken, "text", attr)
+	e.Push(act.CommentToken, "text", attr)
+	assert.Equal(t, 4, act.Stats().TotalCount)
+
+	_, err = e.PushKeyed(act.CommentToken, strings.Repeat("k", PushKeyMaxLength+1), "text", attr)
+	assert.Equal(t, BadPushKeyError, err)
+
+	// keys are per sender, one client cannot get the comment of another by guessing its key
+	from := func(client string, addr string) *LabelComment {
+		lc, err := e.PushFrom(NewSender(act.CommentToken, "shared", client, addr), "text", attr)
+		assert.Nil(t, err)
+		return lc
+	}
+	c1, c2 := e.ClientId(""), e.ClientId("")
+	first := from(c1, "10.0.0.1:5000")
+	assert.Same(t, first, from(c1, "10.0.0.2:5000"))
+	assert.NotSame(t, first, from(c2, "10.0.0.1:5000"))
+	assert.NotSame(t, first, from("", "10.0.0.1:5000"))
+
+	// keys start over with the activity
+	assert.Nil(t, e.Reset(e.AdminToken, act.Id))
+	fresh, _ := e.PushKeyed(act.CommentToken, "retry-1", "text", attr)
+	assert.NotSame(t, lc, fresh)
+}
+
+func TestEngine_SetDedupe(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	push := func(text string) *LabelComment {
+		lc, err := e.Push(act.CommentToken, "text", map[string]string{"text": text, "color": "red"})
+		assert.Nil(t, err)
+		return lc
+	}
+
+	assert.Equal(t, NotAuthorizedError, e.SetDedupe(act.ReviewToken, act.Id, DedupePolicy{Mode: DedupeOff}))
+	assert.Equal(t, IllFormatError, e.SetDedupe(e.AdminToken, act.Id, DedupePolicy{Mode: DedupeCollapse}))
+
+	// collapsed duplicates count on the waiting comment
+	assert.Nil(t, e.SetDedupe(e.AdminToken, act.Id, DedupePolicy{Mode: DedupeCollapse, Window: time.Minute}))
+	lc := push("666")
+	assert.Same(t, lc, push("6666"))
+	assert.Same(t, lc, push("666!"))
+	assert.Equal(t, "3", FlattenComment(lc).Attributes["multiplier"])
+	assert.Equal(t, 1, act.Stats().TotalCount)
+	rcs, _ := e.Review(act.ReviewToken)
+	e.Approve(act.ReviewToken, []int{rcs[0].Id})
+	assert.Same(t, lc, push("666"))
+	dcs, _ := e.Display(act.DisplayToken)
+	assert.Equal(t, 4, dcs[0].Multiplier())
+
+	// once displayed, the next one starts over
+	again := push("666")
+	assert.NotSame(t, lc, again)
+	assert.Equal(t, 1, again.Multiplier())
+	assert.Equal(t, "", FlattenComment(again).Attributes["multiplier"])
+
+	// denied duplicates are kept but never reach review
+	assert.Nil(t, e.SetDedupe(e.AdminToken, act.Id, DedupePolicy{Mode: DedupeDeny, Window: time.Minute}))
+	denied := push("666")
+	assert.Equal(t, CommentStatusDenied, denied.Status)
+	rcs, _ = e.Review(act.ReviewToken)
+	assert.Equal(t, []*LabelComment{again}, rcs)
+	assert.Equal(t, 4, FlattenActivity(act).DuplicateCount)
+	assert.Equal(t, 1, act.Stats().DeniedCount)
+}
+
+func TestEngine_SetDedupeConcurrent(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	assert.Nil(t, e.SetDedupe(e.AdminToken, act.Id, DedupePolicy{Mode: DedupeCollapse, Window: time.Minute}))
+
+	// duplicates pushed at once all count on one comment
+	const n = 20
+	var wg sync.WaitGroup
+	lcs := make([]*LabelComment, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lcs[i], _ = e.Push(act.CommentToken, "text", map[string]string{"text": "666", "color": "red"})
+		}(i)
+	}
+	wg.Wait()
+	for _, lc := range lcs {
+		assert.Same(t, lcs[0], lc)
+	}
+	assert.Equal(t, n, lcs[0].Multiplier())
+	assert.Equal(t, 1, act.Stats().TotalCount)
+}
+
+// comment of another type, for review filters
+type pictureComment string
+
+func (c pictureComment) Type() string                  { return "picture" }
+func (c pictureComment) Content() string               { return string(c) }
+func (c pictureComment) Attributes() map[string]string { return map[string]string{"url": string(c)} }
+
+func TestEngine_ReviewWith(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	push := func(addr string, text string) *LabelComment {
+		lc, err := e.PushFrom(NewSender(act.CommentToken, "", "", addr+":5000"), "text", map[string]string{"text": text, "color": "red"})
+		assert.Nil(t, err)
+		return lc
+	}
+	review := func(o *ReviewOptions) []*LabelComment {
+		cs, err := e.ReviewWith(act.ReviewToken, o)
+		assert.Nil(t, err)
+		return cs
+	}
+
+	_, err := e.ReviewWith(act.CommentToken, &ReviewOptions{})
+	assert.Equal(t, NotAuthorizedError, err)
+	_, err = e.ReviewWith(act.ReviewToken, &ReviewOptions{Order: "random"})
+	assert.Equal(t, IllFormatError, err)
+
+	// newest first, at most two; the rest stay for the next reviewer in arrival order
+	a, b, c := push("10.0.0.1", "a"), push("10.0.0.1", "b"), push("10.0.0.1", "c")
+	assert.Equal(t, []*LabelComment{c, b}, review(&ReviewOptions{Order: ReviewNewestFirst, Limit: 2}))
+	assert.Equal(t, []*LabelComment{a}, review(&ReviewOptions{}))
+	assert.Equal(t, 0, act.Stats().InitialDepth)
+
+	// a team splits the work by type
+	pic := act.AddFrom(pictureComment("cat.png"), "10.0.0.1")
+	text := push("10.0.0.1", "hi")
+	assert.Equal(t, []*LabelComment{pic}, review(&ReviewOptions{Types: []string{"picture"}}))
+	assert.Equal(t, []*LabelComment{text}, review(&ReviewOptions{Types: []string{"text"}}))
+	pic = act.AddFrom(pictureComment("dog.png"), "10.0.0.1")
+	text = push("10.0.0.1", "hi")
+	assert.Equal(t, []*LabelComment{text, pic}, review(&ReviewOptions{Order: ReviewByType, Types: []string{"text", "picture"}}))
+
+	// flagged comments first
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{{Kind: "blocklist", Options: map[string]string{"words": "link", "action": "hold"}}}))
+	plain, flagged := push("10.0.0.1", "hello"), push("10.0.0.1", "a link")
+	assert.Equal(t, []*LabelComment{flagged, plain}, review(&ReviewOptions{FlaggedFirst: true}))
+
+	// reputation follows the decisions on the comments of each sender
+	e.Decide(act.ReviewToken, []int{plain.Id}, []int{flagged.Id, a.Id})
+	e.Approve(act.ReviewToken, []int{b.Id, c.Id})
+	bad := push("10.0.0.2", "bad")
+	review(&ReviewOptions{})
+	e.Deny(act.ReviewToken, []int{bad.Id})
+	fromBad, fromGood, fromNew := push("10.0.0.2", "x"), push("10.0.0.1", "y"), push("10.0.0.3", "z")
+	assert.Equal(t, []*LabelComment{fromGood, fromNew, fromBad}, review(&ReviewOptions{Order: ReviewTrustedFirst}))
+	fromBad, fromGood, fromNew = push("10.0.0.2", "x"), push("10.0.0.1", "y"), push("10.0.0.3", "z")
+	assert.Equal(t, []*LabelComment{fromBad, fromNew, fromGood}, review(&ReviewOptions{Order: ReviewSuspectFirst}))
+}
+
+func TestDanmakuService_ReviewReputation(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.ReviewOn(e.AdminToken, act.Id)
+	push := func(text string, client string) int {
+		reply := &struct {
+			Comment *FlatComment `json:"comment"`
+		}{}
+		assert.Nil(t, s.Push(&Context{}, &struct {
+			Token      string
+			Key        string
+			Client     string
+			Type       string
+			Attr       map[string]string
+			RemoteAddr string // set by RemoteAddrRPC
+		}{act.CommentToken, "", client, "text", map[string]string{"text": text, "color": "red"}, "10.0.0.1:5000"}, reply))
+		return reply.Comment.Id
+	}
+	review := func(order string, limit int) (ids []int) {
+		reply := &struct {
+			Comments []*FlatComment `json:"comments"`
+			Notice   string         `json:"notice,omitempty"`
+		}{}
+		assert.Nil(t, s.Review(&Context{}, &struct {
+			Token        string
+			Order        string
+			FlaggedFirst bool
+			Types        []string
+			Limit        int
+		}{act.ReviewToken, order, false, nil, limit}, reply))
+		for _, c := range reply.Comments {
+			ids = append(ids, c.Id)
+		}
+		return
+	}
+
+	// senders behind one address are known by their client id
+	good, bad := e.ClientId(""), e.ClientId("")
+	g1, b1 := push("thanks", good), push("spam", bad)
+	review("", 0)
+	e.Decide(act.ReviewToken, []int{g1}, []int{b1})
+
+	b2, g2 := push("more spam", bad), push("great talk", good)
+	assert.Equal(t, []int{g2}, review(ReviewTrustedFirst, 1))
+	assert.Equal(t, []int{b2}, review("", 0))
+	g3, b3 := push("good point", good), push("even more spam", bad)
+	assert.Equal(t, []int{b3}, review(ReviewSuspectFirst, 1))
+	assert.Equal(t, []int{g3}, review("", 0))
+}
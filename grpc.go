@@ -0,0 +1,329 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"github.com/antenna3mt/danmaku-server/pb"
+	"github.com/antenna3mt/rpc/json"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"time"
+)
+
+// how often feeds look for new comments
+var GRPCFeedInterval = 200 * time.Millisecond
+
+// gRPC status for an engine error, the code follows its HTTP status
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	e, ok := err.(*json.Error)
+	if !ok {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Unknown, err.Error())
+	}
+	code := codes.Unknown
+	switch e.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests, http.StatusRequestEntityTooLarge:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, e.Message)
+}
+
+func pbComments(cs []*LabelComment) []*pb.Comment {
+	r := make([]*pb.Comment, 0, len(cs))
+	for _, c := range cs {
+		r = append(r, &pb.Comment{Id: int32(c.Id), Type: c.Type, Content: c.Content, Attributes: FlattenComment(c).Attributes})
+	}
+	return r
+}
+
+// comments with their moderation verdicts, for reviewers
+func pbReviewComments(cs []*LabelComment) []*pb.Comment {
+	r := pbComments(cs)
+	for i, c := range cs {
+		for _, v := range c.Verdicts {
+			r[i].Verdicts = append(r[i].Verdicts, &pb.Verdict{Stage: v.Stage, Action: v.Action, Reason: v.Reason})
+		}
+	}
+	return r
+}
+
+func pbActivity(act *Activity) *pb.Activity {
+	f := FlattenActivity(act)
+	return &pb.Activity{
+		Id:             int32(f.Id),
+		Name:           f.Name,
+		CommentToken:   f.CommentToken,
+		ReviewToken:    f.ReviewToken,
+		DisplayToken:   f.DisplayToken,
+		ReviewOn:       f.ReviewOn,
+		Mode:           f.Mode,
+		Origins:        f.Origins,
+		TotalCount:     int32(f.TotalCount),
+		ApprovedCount:  int32(f.ApprovedCount),
+		DeniedCount:    int32(f.DeniedCount),
+		DisplayedCount: int32(f.DisplayedCount),
+		RetractedCount: int32(f.RetractedCount),
+		ReactionCount:  int32(f.ReactionCount),
+	}
+}
+
+func intIds(ids []int32) []int {
+	r := make([]int, 0, len(ids))
+	for _, id := range ids {
+		r = append(r, int(id))
+	}
+	return r
+}
+
+// gRPC mirror of DanmakuService over the same engine, see pb/danmaku.proto
+type GRPCService struct {
+	pb.UnimplementedDanmakuServiceServer
+	E *Engine
+}
+
+func (s *GRPCService) Login(ctx context.Context, req *pb.TokenRequest) (*pb.LoginReply, error) {
+	tp, err := s.E.Login(req.Token)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	reply := &pb.LoginReply{Type: tp}
+	if tp == "comment" {
+		reply.Client = s.E.ClientId("")
+	}
+	return reply, nil
+}
+
+func (s *GRPCService) NewActivity(ctx context.Context, req *pb.NewActivityRequest) (*pb.ActivityReply, error) {
+	act, err := s.E.NewActivity(req.Token, req.Name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.ActivityReply{Activity: pbActivity(act)}, nil
+}
+
+func (s *GRPCService) Activities(ctx context.Context, req *pb.TokenRequest) (*pb.ActivitiesReply, error) {
+	acts, err := s.E.Activities(req.Token)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	reply := &pb.ActivitiesReply{Activities: make([]*pb.Activity, 0, len(acts))}
+	for _, act := range acts {
+		reply.Activities = append(reply.Activities, pbActivity(act))
+	}
+	return reply, nil
+}
+
+func (s *GRPCService) DelActivity(ctx context.Context, req *pb.ActivityRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.DelActivity(req.Token, int(req.Id)))
+}
+
+func (s *GRPCService) RenameActivity(ctx context.Context, req *pb.RenameActivityRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.RenameActivity(req.Token, int(req.Id), req.Name))
+}
+
+func (s *GRPCService) ReviewOn(ctx context.Context, req *pb.ActivityRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.ReviewOn(req.Token, int(req.Id)))
+}
+
+func (s *GRPCService) ReviewOff(ctx context.Context, req *pb.ActivityRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.ReviewOff(req.Token, int(req.Id)))
+}
+
+func (s *GRPCService) GetActivityDigest(ctx context.Context, req *pb.TokenRequest) (*pb.ActivityDigestReply, error) {
+	act, ok := s.E.ActivityByToken(req.Token)
+	if !ok {
+		return nil, grpcError(NotExistError)
+	}
+	f := FlattenActivityDigest(act)
+	return &pb.ActivityDigestReply{Activity: &pb.ActivityDigest{
+		Id:             int32(f.Id),
+		Name:           f.Name,
+		TotalCount:     int32(f.TotalCount),
+		ApprovedCount:  int32(f.ApprovedCount),
+		DeniedCount:    int32(f.DeniedCount),
+		DisplayedCount: int32(f.DisplayedCount),
+		RetractedCount: int32(f.RetractedCount),
+		ReactionCount:  int32(f.ReactionCount),
+	}}, nil
+}
+
+func (s *GRPCService) Reset(ctx context.Context, req *pb.ActivityRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.Reset(req.Token, int(req.Id)))
+}
+
+// address of the client of a call, empty when unknown
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func (s *GRPCService) Push(ctx context.Context, req *pb.PushRequest) (*pb.CommentReply, error) {
+	c, err := s.E.PushFrom(NewSender(req.Token, req.Key, req.Client, peerAddr(ctx)), req.Type, req.Attr)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.CommentReply{Comment: pbComments([]*LabelComment{c})[0]}, nil
+}
+
+func (s *GRPCService) PushBatch(ctx context.Context, req *pb.PushBatchRequest) (*pb.PushBatchReply, error) {
+	items := make([]PushItem, len(req.Comments))
+	for i, c := range req.Comments {
+		items[i] = PushItem{Key: c.Key, Type: c.Type, Attr: c.Attr}
+	}
+	lcs, errs, err := s.E.PushBatchFrom(NewSender(req.Token, "", req.Client, peerAddr(ctx)), items)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	reply := &pb.PushBatchReply{Results: make([]*pb.PushResult, len(lcs))}
+	for i, c := range lcs {
+		if errs[i] != nil {
+			st := status.Convert(grpcError(errs[i]))
+			reply.Results[i] = &pb.PushResult{Code: int32(st.Code()), Error: st.Message()}
+		} else {
+			reply.Results[i] = &pb.PushResult{Comment: pbComments([]*LabelComment{c})[0]}
+		}
+	}
+	return reply, nil
+}
+
+// react with an emoji, count defaults to 1
+func (s *GRPCService) React(ctx context.Context, req *pb.ReactRequest) (*pb.Empty, error) {
+	count := int(req.Count)
+	if count == 0 {
+		count = 1
+	}
+	return &pb.Empty{}, grpcError(s.E.React(req.Token, req.Emoji, count))
+}
+
+func (s *GRPCService) Review(ctx context.Context, req *pb.ReviewRequest) (*pb.ReviewReply, error) {
+	cs, err := s.E.ReviewWith(req.Token, &ReviewOptions{
+		Order:        req.Order,
+		FlaggedFirst: req.FlaggedFirst,
+		Types:        req.Types,
+		Limit:        int(req.Limit),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.ReviewReply{Comments: pbReviewComments(cs), Notice: s.E.Notice()}, nil
+}
+
+func (s *GRPCService) Approve(ctx context.Context, req *pb.IdsRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.Approve(req.Token, intIds(req.Ids)))
+}
+
+func (s *GRPCService) Deny(ctx context.Context, req *pb.IdsRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.Deny(req.Token, intIds(req.Ids)))
+}
+
+func (s *GRPCService) Decide(ctx context.Context, req *pb.DecideRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.Decide(req.Token, intIds(req.Approve), intIds(req.Deny)))
+}
+
+func (s *GRPCService) Retract(ctx context.Context, req *pb.IdsRequest) (*pb.Empty, error) {
+	return &pb.Empty{}, grpcError(s.E.Retract(req.Token, intIds(req.Ids)))
+}
+
+func (s *GRPCService) Display(ctx context.Context, req *pb.TokenRequest) (*pb.DisplayReply, error) {
+	cs, err := s.E.Display(req.Token)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	rcs, err := s.E.Retractions(req.Token)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	reply := &pb.DisplayReply{Comments: pbComments(cs), Retracted: make([]int32, 0, len(rcs)), Notice: s.E.Notice()}
+	for _, c := range rcs {
+		reply.Retracted = append(reply.Retracted, int32(c.Id))
+	}
+	return reply, nil
+}
+
+// poll the engine every GRPCFeedInterval, next sends whatever is new.
+// The feed ends after sending the restart notice once the engine is shutting down,
+// so that a graceful stop is not held up by open streams
+func (s *GRPCService) feed(ctx context.Context, next func() error) error {
+	ticker := time.NewTicker(GRPCFeedInterval)
+	defer ticker.Stop()
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		closing := s.E.Closing()
+		if err := next(); err != nil || closing {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// comments that could not be sent are put back for the next review
+func (s *GRPCService) ReviewFeed(req *pb.TokenRequest, stream grpc.ServerStreamingServer[pb.ReviewReply]) error {
+	return s.feed(stream.Context(), func() error {
+		return grpcError(s.E.ReviewTo(req.Token, &ReviewOptions{}, func(cs []*LabelComment) error {
+			reply := &pb.ReviewReply{Comments: pbReviewComments(cs), Notice: s.E.Notice()}
+			if len(reply.Comments) == 0 && reply.Notice == "" {
+				return nil
+			}
+			return stream.Send(reply)
+		}))
+	})
+}
+
+// comments and retractions that could not be sent are put back for the next display
+func (s *GRPCService) DisplayFeed(req *pb.TokenRequest, stream grpc.ServerStreamingServer[pb.DisplayReply]) error {
+	return s.feed(stream.Context(), func() error {
+		return grpcError(s.E.DisplayTo(req.Token, func(cs []*LabelComment, rcs []*LabelComment) error {
+			reply := &pb.DisplayReply{Comments: pbComments(cs), Retracted: make([]int32, 0, len(rcs)), Notice: s.E.Notice()}
+			for _, c := range rcs {
+				reply.Retracted = append(reply.Retracted, int32(c.Id))
+			}
+			if len(reply.Comments) == 0 && len(reply.Retracted) == 0 && reply.Notice == "" {
+				return nil
+			}
+			return stream.Send(reply)
+		}))
+	})
+}
+
+// gRPC server for the engine, using TLS when conf is not nil
+func NewGRPCServer(e *Engine, conf *tls.Config) *grpc.Server {
+	var opts []grpc.ServerOption
+	if conf != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(conf)))
+	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterDanmakuServiceServer(srv, &GRPCService{E: e})
+	return srv
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"github.com/antenna3mt/danmaku-server/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"testing"
+	"time"
+)
+
+func newGRPCClient(t *testing.T, e *Engine) pb.DanmakuServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := NewGRPCServer(e, nil)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewDanmakuServiceClient(conn)
+}
+
+func TestGRPCService(t *testing.T) {
+	e := NewEngine()
+	c := newGRPCClient(t, e)
+	ctx := context.Background()
+
+	_, err := c.Activities(ctx, &pb.TokenRequest{Token: "nope"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	res, err := c.NewActivity(ctx, &pb.NewActivityRequest{Token: e.AdminToken, Name: "Hello"})
+	assert.NoError(t, err)
+	act := res.Activity
+	assert.Equal(t, "Hello", act.Name)
+
+	login, err := c.Login(ctx, &pb.TokenRequest{Token: act.ReviewToken})
+	assert.NoError(t, err)
+	assert.Equal(t, "review", login.Type)
+	assert.Equal(t, "", login.Client)
+	login, err = c.Login(ctx, &pb.TokenRequest{Token: act.CommentToken})
+	assert.NoError(t, err)
+	assert.True(t, e.ValidClientId(login.Client))
+
+	_, err = c.ReviewOn(ctx, &pb.ActivityRequest{Token: e.AdminToken, Id: act.Id})
+	assert.NoError(t, err)
+
+	_, err = c.Push(ctx, &pb.PushRequest{Token: act.CommentToken, Type: "text", Attr: map[string]string{}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	pushed, err := c.Push(ctx, &pb.PushRequest{Token: act.CommentToken, Client: login.Client, Type: "text", Attr: map[string]string{"text": "hi", "color": "red"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", pushed.Comment.Content)
+
+	batch, err := c.PushBatch(ctx, &pb.PushBatchRequest{Token: act.CommentToken, Comments: []*pb.PushItem{
+		{Type: "text", Attr: map[string]string{"text": "one", "color": "red"}},
+		{Type: "picture"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "one", batch.Results[0].Comment.Content)
+	assert.Equal(t, int32(codes.InvalidArgument), batch.Results[1].Code)
+
+	review, err := c.Review(ctx, &pb.ReviewRequest{Token: act.ReviewToken})
+	assert.NoError(t, err)
+	assert.Len(t, review.Comments, 2)
+	_, err = c.Decide(ctx, &pb.DecideRequest{Token: act.ReviewToken, Deny: []int32{batch.Results[0].Comment.Id}})
+	assert.NoError(t, err)
+
+	_, err = c.Approve(ctx, &pb.IdsRequest{Token: act.ReviewToken, Ids: []int32{pushed.Comment.Id}})
+	assert.NoError(t, err)
+	display, err := c.Display(ctx, &pb.TokenRequest{Token: act.DisplayToken})
+	assert.NoError(t, err)
+	assert.Len(t, display.Comments, 1)
+	assert.Nil(t, display.Comments[0].Verdicts)
+
+	// reviewers see why moderation held a comment
+	assert.Nil(t, e.SetModeration(e.AdminToken, int(act.Id), []StageConfig{{Kind: "blocklist", Options: map[string]string{"words": "link", "action": "hold"}}}))
+	_, err = c.Push(ctx, &pb.PushRequest{Token: act.CommentToken, Type: "text", Attr: map[string]string{"text": "a link", "color": "red"}})
+	assert.NoError(t, err)
+	review, err = c.Review(ctx, &pb.ReviewRequest{Token: act.ReviewToken})
+	assert.NoError(t, err)
+	assert.Len(t, review.Comments, 1)
+	assert.Len(t, review.Comments[0].Verdicts, 1)
+	assert.Equal(t, "blocklist#1", review.Comments[0].Verdicts[0].Stage)
+	assert.Equal(t, VerdictHold, review.Comments[0].Verdicts[0].Action)
+	assert.Equal(t, `blocked word "link"`, review.Comments[0].Verdicts[0].Reason)
+
+	digest, err := c.GetActivityDigest(ctx, &pb.TokenRequest{Token: act.DisplayToken})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), digest.Activity.DisplayedCount)
+}
+
+func TestGRPCService_Feeds(t *testing.T) {
+	defer func(d time.Duration) { GRPCFeedInterval = d }(GRPCFeedInterval)
+	GRPCFeedInterval = 5 * time.Millisecond
+
+	e := NewEngine()
+	c := newGRPCClient(t, e)
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.ReviewOn(e.AdminToken, act.Id)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bad, err := c.DisplayFeed(ctx, &pb.TokenRequest{Token: "nope"})
+	assert.NoError(t, err)
+	_, err = bad.Recv()
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	reviews, err := c.ReviewFeed(ctx, &pb.TokenRequest{Token: act.ReviewToken})
+	assert.NoError(t, err)
+	displays, err := c.DisplayFeed(ctx, &pb.TokenRequest{Token: act.DisplayToken})
+	assert.NoError(t, err)
+
+	lc, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	r, err := reviews.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(lc.Id), r.Comments[0].Id)
+
+	e.Approve(act.ReviewToken, []int{lc.Id})
+	d, err := displays.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(lc.Id), d.Comments[0].Id)
+
+	e.Retract(act.ReviewToken, []int{lc.Id})
+	d, err = displays.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{int32(lc.Id)}, d.Retracted)
+
+	// feeds end with the restart notice
+	e.Shutdown("server restarting")
+	d, err = displays.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "server restarting", d.Notice)
+	_, err = displays.Recv()
+	assert.Error(t, err)
+	r, err = reviews.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "server restarting", r.Notice)
+}
+
+// server side of a stream whose client is gone
+type brokenStream[T any] struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *brokenStream[T]) Context() context.Context { return s.ctx }
+func (s *brokenStream[T]) Send(*T) error            { return status.Error(codes.Canceled, "gone") }
+
+func TestGRPCService_FeedsCancelled(t *testing.T) {
+	e := NewEngine()
+	s := &GRPCService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.ReviewOn(e.AdminToken, act.Id)
+	lc, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Hello", "color": "red"})
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// nothing is taken for a cancelled stream, nor lost when sending fails
+	err := s.ReviewFeed(&pb.TokenRequest{Token: act.ReviewToken}, &brokenStream[pb.ReviewReply]{ctx: cancelled})
+	assert.Equal(t, context.Canceled, err)
+	err = s.ReviewFeed(&pb.TokenRequest{Token: act.ReviewToken}, &brokenStream[pb.ReviewReply]{ctx: context.Background()})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, 0, act.Stats().PendingDepth)
+	assert.Equal(t, 1, act.Stats().InitialDepth)
+	rcs, _ := e.Review(act.ReviewToken)
+	assert.Equal(t, []*LabelComment{lc}, rcs)
+
+	e.Approve(act.ReviewToken, []int{lc.Id})
+	err = s.DisplayFeed(&pb.TokenRequest{Token: act.DisplayToken}, &brokenStream[pb.DisplayReply]{ctx: cancelled})
+	assert.Equal(t, context.Canceled, err)
+	err = s.DisplayFeed(&pb.TokenRequest{Token: act.DisplayToken}, &brokenStream[pb.DisplayReply]{ctx: context.Background()})
+	assert.Equal(t, codes.Canceled, status.Code(err))
+	assert.Equal(t, CommentStatusApproved, lc.Status)
+	assert.Equal(t, 0, act.Stats().DisplayedCount)
+	dcs, _ := e.Display(act.DisplayToken)
+	assert.Equal(t, []*LabelComment{lc}, dcs)
+}
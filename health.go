@@ -0,0 +1,84 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// build information, set with -ldflags "-X main.Version=... -X main.Commit=..."
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// a named readiness condition
+type ReadyCheck struct {
+	Name  string
+	Check func() error
+}
+
+// commit of the build, from ldflags or the VCS information embedded by the go tool
+func buildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// probe endpoints for orchestrators: /healthz is up while the process serves,
+// /readyz when the engine accepts pushes and every check passes, /version describes the build
+func NewHealthHandler(e *Engine, started time.Time, checks ...ReadyCheck) http.Handler {
+	checks = append([]ReadyCheck{{"engine", func() error {
+		if e.Closing() {
+			return errors.New("shutting down")
+		}
+		return nil
+	}}}, checks...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		res := make(map[string]string, len(checks))
+		for _, c := range checks {
+			if err := c.Check(); err != nil {
+				status = http.StatusServiceUnavailable
+				res[c.Name] = err.Error()
+			} else {
+				res[c.Name] = "ok"
+			}
+		}
+		writeJSON(w, status, res)
+	})
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		acts, _ := e.Activities(e.AdminToken)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"version":    Version,
+			"commit":     buildCommit(),
+			"started_at": started.UTC().Format(time.RFC3339),
+			"activities": len(acts),
+		})
+	})
+	return mux
+}
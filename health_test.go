@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthHandler(t *testing.T) {
+	e := NewEngine()
+	e.NewActivity(e.AdminToken, "Hello")
+	started := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	var storage error
+	h := NewHealthHandler(e, started, ReadyCheck{"storage", func() error { return storage }})
+
+	get := func(path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, _ := get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"engine": "ok", "storage": "ok"}, body)
+
+	storage = errors.New("read-only")
+	code, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "read-only", body["storage"])
+
+	storage = nil
+	e.Shutdown("server restarting")
+	code, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	code, body = get("/version")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Version, body["version"])
+	assert.Equal(t, "2018-05-01T12:00:00Z", body["started_at"])
+	assert.Equal(t, float64(1), body["activities"])
+}
@@ -0,0 +1,155 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// the parts of a JSON-RPC request worth logging
+type rpcRequest struct {
+	Method string
+	Token  string
+	Id     int
+}
+
+// read the JSON-RPC request, leaving the body intact for the next handler;
+// params may be a single object or an array holding one
+func peekRequest(r *http.Request) (req rpcRequest) {
+	reqs := peekRequests(r)
+	if len(reqs) > 0 {
+		req = reqs[0]
+	}
+	return
+}
+
+// read the calls of a JSON-RPC request, one for a single call or one per call of a batch,
+// leaving the body intact for the next handler; none for bodies over BatchMaxBytes, which
+// are only read that far and left for the next handler to refuse
+func peekRequests(r *http.Request) []rpcRequest {
+	if r.Body == nil {
+		return nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, BatchMaxBytes+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || int64(len(body)) > BatchMaxBytes {
+		return nil
+	}
+
+	var calls []json.RawMessage
+	if json.Unmarshal(body, &calls) != nil {
+		calls = []json.RawMessage{body}
+	}
+	reqs := make([]rpcRequest, len(calls))
+	for i, call := range calls {
+		reqs[i] = parseRequest(call)
+	}
+	return reqs
+}
+
+func parseRequest(call []byte) (req rpcRequest) {
+	var env struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if json.Unmarshal(call, &env) != nil {
+		return
+	}
+	req.Method = env.Method
+
+	var params struct {
+		Token string
+		Id    int
+	}
+	var list []json.RawMessage
+	if json.Unmarshal(env.Params, &list) == nil && len(list) > 0 {
+		json.Unmarshal(list[0], &params)
+	} else {
+		json.Unmarshal(env.Params, &params)
+	}
+	req.Token = params.Token
+	req.Id = params.Id
+	return
+}
+
+// response writer keeping a copy of the body
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// code and message of a JSON-RPC error response, zero code and empty message on success
+func responseError(body []byte) (int, string) {
+	var res struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &res) != nil || len(res.Error) == 0 || string(res.Error) == "null" {
+		return 0, ""
+	}
+	var e struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(res.Error, &e) != nil {
+		json.Unmarshal(res.Error, &e.Message)
+	}
+	return e.Code, e.Message
+}
+
+// wrap a JSON-RPC handler to log every call with its method, the role and activity of the token,
+// latency and error; tokens themselves are never logged
+func LogRPC(e *Engine, logger *slog.Logger, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.ServeHTTP(w, r)
+			return
+		}
+		req := peekRequest(r)
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		h.ServeHTTP(rw, r)
+		latency := time.Since(start)
+
+		role, _ := e.Login(req.Token)
+		activityId := req.Id
+		if act, ok := e.ActivityByToken(req.Token); ok {
+			activityId = act.Id
+		}
+		code, message := responseError(rw.body.Bytes())
+
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("role", role),
+			slog.Int("activity", activityId),
+			slog.Duration("latency", latency),
+			slog.Int("status", rw.status),
+		}
+		level := slog.LevelInfo
+		if code != 0 || message != "" {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.Int("error_code", code), slog.String("error", message))
+		}
+		logger.LogAttrs(r.Context(), level, "rpc", attrs...)
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPeekRequest(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"method":"DanmakuService.Reset","params":[{"Token":"t","Id":3}],"id":1}`))
+	assert.Equal(t, rpcRequest{Method: "DanmakuService.Reset", Token: "t", Id: 3}, peekRequest(r))
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"method":"DanmakuService.Reset","params":{"Token":"t"},"id":1}`))
+	assert.Equal(t, rpcRequest{Method: "DanmakuService.Reset", Token: "t"}, peekRequest(r))
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`garbage`))
+	assert.Equal(t, rpcRequest{}, peekRequest(r))
+
+	// bodies over the limit are only read that far, and left intact
+	defer func(n int64) { BatchMaxBytes = n }(BatchMaxBytes)
+	BatchMaxBytes = 16
+	body := `{"method":"DanmakuService.Reset","params":[{"Token":"t"}],"id":1}`
+	r = httptest.NewRequest("POST", "/", strings.NewReader(body))
+	assert.Nil(t, peekRequests(r))
+	b, _ := ioutil.ReadAll(r.Body)
+	assert.Equal(t, body, string(b))
+}
+
+func TestLogRPC(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	h := LogRPC(e, logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"result":null,"error":{"code":404,"message":"not exist"},"id":1}`))
+	}))
+	body := `{"method":"DanmakuService.Review","params":[{"Token":"` + act.ReviewToken + `"}],"id":1}`
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)))
+
+	assert.NotContains(t, buf.String(), act.ReviewToken)
+	var line map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "DanmakuService.Review", line["method"])
+	assert.Equal(t, "review", line["role"])
+	assert.Equal(t, float64(act.Id), line["activity"])
+	assert.Equal(t, float64(404), line["error_code"])
+	assert.Equal(t, "WARN", line["level"])
+}
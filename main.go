@@ -4,28 +4,125 @@
 package main
 
 import (
+	"context"
 	"log"
 	"github.com/antenna3mt/rpc"
 	"github.com/antenna3mt/rpc/json"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"fmt"
-	"github.com/rs/cors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
-	engine := NewEngine()
-	engine.NewActivityFull(engine.AdminToken, "Test Activity", "cc123456", "rr123456", "dd123456")
-	fmt.Println(engine.AdminToken)
+	started := time.Now()
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	cfg, err := LoadConfig(os.Args[0], os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Apply()
+	engine, err := cfg.NewEngine()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.AdminToken == "" {
+		fmt.Println(engine.AdminToken)
+	}
 	server, err := rpc.NewServer(new(Context))
 	if err != nil {
 		log.Fatal(err)
 	}
 	server.RegisterCodec(json.NewCodec(), "application/json")
-	server.RegisterService(&DanmakuService{
+	service := &DanmakuService{
 		E: engine,
-	}, "")
-	http.Handle("/", cors.Default().Handler(server))
-	if err := http.ListenAndServe(":8881", nil); err != nil {
+	}
+	server.RegisterService(service, "")
+	health := NewHealthHandler(engine, started)
+	http.Handle("/healthz", health)
+	http.Handle("/readyz", health)
+	http.Handle("/version", health)
+	http.Handle("/metrics", NewMetricsHandler(engine))
+	rest := NewCORSHandler(engine, cfg.CORS(), NewRESTHandler(engine))
+	for _, path := range []string{"/activities", "/activities/", "/review", "/review/", "/display", "/openapi.json"} {
+		http.Handle(path, rest)
+	}
+	http.Handle("/", NewCORSHandler(engine, cfg.CORS(), BatchRPC(RemoteAddrRPC(LogRPC(engine, logger, InstrumentRPC(service, server))))))
+	tlsConfig, err := NewTLSConfig(cfg, logger)
+	if err != nil {
 		log.Fatal(err)
 	}
+	srv := &http.Server{Addr: cfg.Listen, TLSConfig: tlsConfig}
+	servers := []*http.Server{srv}
+	errs := make(chan error, 3)
+	logger.Info("listening", "addr", cfg.Listen, "tls", cfg.TLS(), "version", Version)
+	go func() {
+		if cfg.TLS() {
+			errs <- srv.ListenAndServeTLS("", "")
+		} else {
+			errs <- srv.ListenAndServe()
+		}
+	}()
+	if cfg.RedirectListen != "" {
+		redirect := &http.Server{Addr: cfg.RedirectListen, Handler: RedirectHandler(cfg.Listen)}
+		servers = append(servers, redirect)
+		logger.Info("redirecting to https", "addr", cfg.RedirectListen)
+		go func() { errs <- redirect.ListenAndServe() }()
+	}
+	var grpcServer *grpc.Server
+	if cfg.GRPCListen != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCListen)
+		if err != nil {
+			log.Fatal(err)
+		}
+		grpcServer = NewGRPCServer(engine, tlsConfig)
+		logger.Info("serving grpc", "addr", cfg.GRPCListen, "tls", cfg.TLS())
+		go func() { errs <- grpcServer.Serve(lis) }()
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		log.Fatal(err)
+	case s := <-sig:
+		logger.Info("shutting down", "signal", s.String())
+	}
+	shutdown(engine, servers, grpcServer, cfg, logger)
+}
+
+// stop taking pushes, let polling display and review clients see the restart notice,
+// then drain in-flight requests until the timeout; gRPC feeds end by themselves
+// after sending the notice
+func shutdown(engine *Engine, servers []*http.Server, grpcServer *grpc.Server, cfg *Config, logger *slog.Logger) {
+	engine.Shutdown("server restarting")
+	time.Sleep(cfg.ShutdownNotice)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Error("shutdown", "addr", srv.Addr, "error", err)
+		}
+	}
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+			logger.Error("shutdown", "addr", cfg.GRPCListen, "error", ctx.Err())
+		}
+	}
+	// comments live in memory only, there is no storage to flush yet
+	logger.Info("stopped")
 }
@@ -0,0 +1,143 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	rpcjson "github.com/antenna3mt/rpc/json"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const MetricsNamespace = "danmaku"
+
+var (
+	pushRejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Name:      "push_rejected_total",
+		Help:      "Pushes rejected, by error.",
+	}, []string{"error"})
+
+	rpcDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Latency of RPC calls, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	activityLabels = []string{"activity"}
+
+	queueDepthDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "queue_depth"),
+		"Comments waiting in a queue of an activity.", []string{"activity", "queue"}, nil)
+	pushesDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "pushes_total"),
+		"Comments pushed to an activity.", activityLabels, nil)
+	approvalsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "approvals_total"),
+		"Comments approved in an activity.", activityLabels, nil)
+	denialsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "denials_total"),
+		"Comments denied in an activity.", activityLabels, nil)
+	displaysDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "displays_total"),
+		"Comments displayed in an activity.", activityLabels, nil)
+	retractionsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "retractions_total"),
+		"Comments retracted in an activity.", activityLabels, nil)
+	reactionsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "reactions_total"),
+		"Reactions sent to an activity.", activityLabels, nil)
+	retainedDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "retained_comments"),
+		"Comments kept in memory by an activity.", activityLabels, nil)
+	dropsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "drops_total"),
+		"Comments dropped from full queues of an activity.", activityLabels, nil)
+	evictionsDesc = prometheus.NewDesc(prometheus.BuildFQName(MetricsNamespace, "", "evictions_total"),
+		"Finished comments evicted from memory by an activity.", activityLabels, nil)
+)
+
+// label of an error returned to clients
+func errorLabel(err error) string {
+	if e, ok := err.(*rpcjson.Error); ok {
+		return e.Message
+	}
+	return "internal"
+}
+
+// count a rejected push
+func observePushRejected(err error) {
+	pushRejectedCounter.WithLabelValues(errorLabel(err)).Inc()
+}
+
+// engineCollector reports queue depths and counters of every activity at scrape time,
+// so deleted activities disappear and reset ones start over
+type engineCollector struct {
+	e *Engine
+}
+
+func (c *engineCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{queueDepthDesc, pushesDesc, approvalsDesc, denialsDesc, displaysDesc, retractionsDesc, reactionsDesc, retainedDesc, dropsDesc, evictionsDesc} {
+		ch <- d
+	}
+}
+
+func (c *engineCollector) Collect(ch chan<- prometheus.Metric) {
+	acts, _ := c.e.Activities(c.e.AdminToken)
+	for _, act := range acts {
+		id := strconv.Itoa(act.Id)
+		st := act.Stats()
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(st.InitialDepth), id, "initial")
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(st.PendingDepth), id, "pending")
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(st.ApprovedDepth), id, "approved")
+		ch <- prometheus.MustNewConstMetric(pushesDesc, prometheus.CounterValue, float64(st.TotalCount), id)
+		ch <- prometheus.MustNewConstMetric(approvalsDesc, prometheus.CounterValue, float64(st.ApprovedCount), id)
+		ch <- prometheus.MustNewConstMetric(denialsDesc, prometheus.CounterValue, float64(st.DeniedCount), id)
+		ch <- prometheus.MustNewConstMetric(displaysDesc, prometheus.CounterValue, float64(st.DisplayedCount), id)
+		ch <- prometheus.MustNewConstMetric(retractionsDesc, prometheus.CounterValue, float64(st.RetractedCount), id)
+		ch <- prometheus.MustNewConstMetric(reactionsDesc, prometheus.CounterValue, float64(act.Reactions.Count()), id)
+		ch <- prometheus.MustNewConstMetric(retainedDesc, prometheus.GaugeValue, float64(st.RetainedCount), id)
+		ch <- prometheus.MustNewConstMetric(dropsDesc, prometheus.CounterValue, float64(st.DroppedCount), id)
+		ch <- prometheus.MustNewConstMetric(evictionsDesc, prometheus.CounterValue, float64(st.EvictedCount), id)
+	}
+}
+
+// handler serving the metrics of the engine and the process
+func NewMetricsHandler(e *Engine) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		&engineCollector{e: e},
+		pushRejectedCounter,
+		rpcDurationHistogram,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+// exported methods of a service, from the name used in JSON-RPC requests to the bare method name
+func serviceMethods(service interface{}) map[string]string {
+	t := reflect.TypeOf(service)
+	name := reflect.Indirect(reflect.ValueOf(service)).Type().Name()
+	r := make(map[string]string, t.NumMethod())
+	for i := 0; i < t.NumMethod(); i++ {
+		r[name+"."+t.Method(i).Name] = t.Method(i).Name
+	}
+	return r
+}
+
+// wrap a JSON-RPC handler to observe the latency of each method of service;
+// unknown methods share one label to keep cardinality bounded
+func InstrumentRPC(service interface{}, h http.Handler) http.Handler {
+	methods := serviceMethods(service)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h.ServeHTTP(w, r)
+			return
+		}
+		method, ok := methods[peekRequest(r).Method]
+		if !ok {
+			method = "unknown"
+		}
+		start := time.Now()
+		h.ServeHTTP(w, r)
+		rpcDurationHistogram.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	})
+}
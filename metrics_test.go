@@ -0,0 +1,66 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.Push(act.CommentToken, "text", map[string]string{"text": "a", "color": "red"})
+	e.Push(act.CommentToken, "text", map[string]string{"text": "b", "color": "red"})
+	e.Push(act.CommentToken, "text", map[string]string{"text": "c", "color": "red"})
+	rcs, _ := e.Review(act.ReviewToken)
+	e.Approve(act.ReviewToken, []int{rcs[0].Id})
+	e.Push(act.CommentToken, "text", map[string]string{"text": "d", "color": "red"})
+
+	before := testutil.ToFloat64(pushRejectedCounter.WithLabelValues(BadColorError.Message))
+	e.Push(act.CommentToken, "text", map[string]string{"text": "e", "color": "reddish"})
+	assert.Equal(t, before+1, testutil.ToFloat64(pushRejectedCounter.WithLabelValues(BadColorError.Message)))
+
+	w := httptest.NewRecorder()
+	NewMetricsHandler(e).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+	for _, line := range []string{
+		`danmaku_queue_depth{activity="1",queue="initial"} 1`,
+		`danmaku_queue_depth{activity="1",queue="pending"} 2`,
+		`danmaku_queue_depth{activity="1",queue="approved"} 1`,
+		`danmaku_pushes_total{activity="1"} 4`,
+		`danmaku_approvals_total{activity="1"} 1`,
+		`danmaku_push_rejected_total{error="color not allowed"}`,
+	} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestInstrumentRPC(t *testing.T) {
+	var got string
+	h := InstrumentRPC(&DanmakuService{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got = string(b)
+	}))
+
+	// unknown methods are observed under one label
+	samples := func(method string) uint64 {
+		m := &dto.Metric{}
+		assert.Nil(t, rpcDurationHistogram.WithLabelValues(method).(prometheus.Metric).Write(m))
+		return m.GetHistogram().GetSampleCount()
+	}
+	push, unknown := samples("Push"), samples("unknown")
+	body := `{"method":"DanmakuService.Push","params":[{}],"id":1}`
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	assert.Equal(t, body, got)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(`{"method":"Evil.Method"}`)))
+	assert.Equal(t, push+1, samples("Push"))
+	assert.Equal(t, unknown+1, samples("unknown"))
+
+	assert.Equal(t, "Push", serviceMethods(&DanmakuService{})["DanmakuService.Push"])
+}
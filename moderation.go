@@ -0,0 +1,347 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// actions of a moderation verdict
+const (
+	// no objection, the next stage decides
+	VerdictAllow = "allow"
+	// refuse the comment, it is kept as denied and never reviewed
+	VerdictDeny = "deny"
+	// send the comment to human review even when review is off
+	VerdictHold = "hold"
+	// approve the comment without review unless another stage holds it
+	VerdictApprove = "approve"
+	// replace the comment by Comment and go on
+	VerdictRewrite = "rewrite"
+	// count the comment on Into instead of adding it
+	VerdictCollapse = "collapse"
+	// refuse the comment as sent too often; it is neither kept nor announced
+	VerdictThrottle = "throttle"
+)
+
+// who pushed a comment; Client is the client id issued by the engine if the client passed one,
+// Addr is the client IP when the transport knows it
+type Sender struct {
+	Token  string
+	Key    string
+	Client string
+	Addr   string
+}
+
+// sender with the host part of a remote address
+func NewSender(token string, key string, client string, remoteAddr string) *Sender {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return &Sender{Token: token, Key: key, Client: client, Addr: host}
+}
+
+// identity used to tell senders apart, their client id or else their address; keys are
+// chosen by clients and never trusted as one
+func (s *Sender) id() string {
+	if s.Client != "" {
+		return s.Client
+	}
+	return s.Addr
+}
+
+// outcome of a moderation stage; Stage is set by the chain. Done, if not nil, is called
+// with the comment once added, or nil if it was not, whatever the verdict
+type Verdict struct {
+	Stage   string
+	Action  string
+	Reason  string
+	Comment Comment
+	Into    *LabelComment
+	Done    func(lc *LabelComment)
+}
+
+// a moderation stage: looks at a comment about to be added to an activity
+type Moderator interface {
+	Moderate(act *Activity, c Comment, s *Sender) Verdict
+}
+
+// build a moderator of a kind from its options
+type ModeratorFactory func(options map[string]string) (Moderator, error)
+
+var (
+	moderatorMutex     sync.RWMutex
+	moderatorFactories = map[string]ModeratorFactory{
+		"blocklist":  NewBlocklistModerator,
+		"ratelimit":  NewRateLimitModerator,
+		"dedupe":     NewDedupeModerator,
+		"classifier": NewClassifierModerator,
+	}
+
+	// chain of new activities
+	DefaultModeration = []StageConfig{{Kind: "dedupe"}}
+)
+
+// make a kind of moderator available to moderation chains
+func RegisterModerator(kind string, f ModeratorFactory) {
+	moderatorMutex.Lock()
+	defer moderatorMutex.Unlock()
+
+	moderatorFactories[kind] = f
+}
+
+// registered kinds of moderators, sorted
+func ModeratorKinds() []string {
+	moderatorMutex.RLock()
+	defer moderatorMutex.RUnlock()
+
+	kinds := make([]string, 0, len(moderatorFactories))
+	for k := range moderatorFactories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// kind and options of a stage
+type StageConfig struct {
+	Kind    string
+	Options map[string]string
+}
+
+// stage of a chain, named after its kind and position such as "blocklist#1"
+type ModerationStage struct {
+	StageConfig
+	Name      string
+	Moderator Moderator
+}
+
+// ordered moderation stages of an activity; never modified once built
+type ModerationChain []*ModerationStage
+
+// build a chain of stages; an unknown kind or bad options fail the whole chain
+func NewModerationChain(configs []StageConfig) (ModerationChain, error) {
+	moderatorMutex.RLock()
+	defer moderatorMutex.RUnlock()
+
+	chain := make(ModerationChain, 0, len(configs))
+	dedupe := false
+	for i, c := range configs {
+		f, ok := moderatorFactories[c.Kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown moderator %q", c.Kind)
+		}
+		// a second stage would wait for the group the first one holds for the same push
+		if c.Kind == "dedupe" {
+			if dedupe {
+				return nil, fmt.Errorf("more than one dedupe stage")
+			}
+			dedupe = true
+		}
+		m, err := f(c.Options)
+		if err != nil {
+			return nil, fmt.Errorf("moderator %q: %v", c.Kind, err)
+		}
+		chain = append(chain, &ModerationStage{StageConfig: c, Name: c.Kind + "#" + strconv.Itoa(i+1), Moderator: m})
+	}
+	return chain, nil
+}
+
+// kinds and options of the stages
+func (chain ModerationChain) Configs() []StageConfig {
+	configs := make([]StageConfig, len(chain))
+	for i, stage := range chain {
+		configs[i] = stage.StageConfig
+	}
+	return configs
+}
+
+// outcome of a chain: the comment as rewritten along the way and the verdicts other than
+// allow, the last one being deny or collapse if the chain stopped there
+type Moderation struct {
+	Comment  Comment
+	Verdicts []Verdict
+	done     []func(lc *LabelComment)
+}
+
+// the verdict ending the chain, deny or collapse, if any
+func (m *Moderation) Final() (Verdict, bool) {
+	if n := len(m.Verdicts); n > 0 && IsOneOf(m.Verdicts[n-1].Action, VerdictDeny, VerdictCollapse, VerdictThrottle) {
+		return m.Verdicts[n-1], true
+	}
+	return Verdict{}, false
+}
+
+// tell the stages waiting for it the comment added, nil if none was; only the first call counts
+func (m *Moderation) Done(lc *LabelComment) {
+	for _, f := range m.done {
+		f(lc)
+	}
+	m.done = nil
+}
+
+// run the stages in order; the chain stops at deny, collapse or throttle. A stage panicking
+// releases what the stages before it hold
+func (chain ModerationChain) Moderate(act *Activity, c Comment, s *Sender) *Moderation {
+	m := &Moderation{}
+	defer func() {
+		if r := recover(); r != nil {
+			m.Done(nil)
+			panic(r)
+		}
+	}()
+	for _, stage := range chain {
+		v := stage.Moderator.Moderate(act, c, s)
+		v.Stage = stage.Name
+		if v.Done != nil {
+			m.done = append(m.done, v.Done)
+			v.Done = nil
+		}
+		switch v.Action {
+		case VerdictAllow, "":
+			continue
+		case VerdictRewrite:
+			if v.Comment == nil {
+				continue
+			}
+			c = v.Comment
+		}
+		m.Verdicts = append(m.Verdicts, v)
+		if IsOneOf(v.Action, VerdictDeny, VerdictCollapse, VerdictThrottle) {
+			break
+		}
+	}
+	m.Comment = c
+	return m
+}
+
+// denies or holds text comments containing any of the words, ignoring case;
+// options: words, comma separated, and action, deny by default or hold
+type BlocklistModerator struct {
+	Words  []string
+	Action string
+}
+
+func NewBlocklistModerator(options map[string]string) (Moderator, error) {
+	m := &BlocklistModerator{Words: splitList(strings.ToLower(options["words"])), Action: options["action"]}
+	if m.Action == "" {
+		m.Action = VerdictDeny
+	}
+	if !IsOneOf(m.Action, VerdictDeny, VerdictHold) {
+		return nil, fmt.Errorf("action must be %s or %s", VerdictDeny, VerdictHold)
+	}
+	if len(m.Words) == 0 {
+		return nil, fmt.Errorf("no words")
+	}
+	return m, nil
+}
+
+func (m *BlocklistModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	text := strings.ToLower(c.Content())
+	for _, w := range m.Words {
+		if strings.Contains(text, w) {
+			return Verdict{Action: m.Action, Reason: "blocked word " + strconv.Quote(w)}
+		}
+	}
+	return Verdict{Action: VerdictAllow}
+}
+
+// throttles comments of a sender beyond count within window, senders told apart by client id
+// or else address; senders with neither are not limited. Options: count, window as a duration
+// such as 10s
+type RateLimitModerator struct {
+	Count  int
+	Window time.Duration
+	mutex  sync.Mutex
+	sent   map[string][]time.Time
+	now    func() time.Time
+}
+
+// senders remembered by a rate limit at most, the limit is lifted for others
+const rateLimitMaxSenders = 10000
+
+func NewRateLimitModerator(options map[string]string) (Moderator, error) {
+	count, err := strconv.Atoi(options["count"])
+	if err != nil || count <= 0 {
+		return nil, fmt.Errorf("count must be a positive number")
+	}
+	window, err := time.ParseDuration(options["window"])
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("window must be a positive duration")
+	}
+	return &RateLimitModerator{Count: count, Window: window, sent: make(map[string][]time.Time), now: time.Now}, nil
+}
+
+func (m *RateLimitModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	id := s.id()
+	if id == "" {
+		return Verdict{Action: VerdictAllow}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := m.now()
+	ts := m.sent[id]
+	n := 0
+	for n < len(ts) && now.Sub(ts[n]) >= m.Window {
+		n++
+	}
+	ts = ts[n:]
+	if len(ts) >= m.Count {
+		m.sent[id] = ts
+		return Verdict{Action: VerdictThrottle, Reason: "rate limited"}
+	}
+	if _, ok := m.sent[id]; !ok && len(m.sent) >= rateLimitMaxSenders {
+		m.expire(now)
+		if len(m.sent) >= rateLimitMaxSenders {
+			return Verdict{Action: VerdictAllow}
+		}
+	}
+	m.sent[id] = append(ts, now)
+	return Verdict{Action: VerdictAllow}
+}
+
+// forget senders quiet for the window; must hold the mutex
+func (m *RateLimitModerator) expire(now time.Time) {
+	for id, ts := range m.sent {
+		if len(ts) == 0 || now.Sub(ts[len(ts)-1]) >= m.Window {
+			delete(m.sent, id)
+		}
+	}
+}
+
+// duplicate suppression as set by SetDedupe, see DedupePolicy; no options
+type DedupeModerator struct{}
+
+func NewDedupeModerator(options map[string]string) (Moderator, error) {
+	return DedupeModerator{}, nil
+}
+
+func (DedupeModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	p := act.Settings().Dedupe
+	if !p.on() {
+		return Verdict{Action: VerdictAllow}
+	}
+	var collapse func(lc *LabelComment) bool
+	if p.Mode == DedupeCollapse {
+		collapse = act.Collapse
+	}
+	orig, fill := act.Dedupe.Match(p, c.Content(), collapse)
+	if orig == nil {
+		return Verdict{Action: VerdictAllow, Done: fill}
+	}
+	act.Dedupe.Suppressed()
+	if p.Mode == DedupeDeny {
+		return Verdict{Action: VerdictDeny, Reason: fmt.Sprintf("duplicate of %d", orig.Id)}
+	}
+	return Verdict{Action: VerdictCollapse, Reason: fmt.Sprintf("duplicate of %d", orig.Id), Into: orig}
+}
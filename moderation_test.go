@@ -0,0 +1,203 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"github.com/antenna3mt/rpc/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+)
+
+// panics on comments saying so
+type panicModerator struct{}
+
+func (panicModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	if c.Content() == "panic" {
+		panic("moderator failed")
+	}
+	return Verdict{Action: VerdictAllow}
+}
+
+// rewrites text comments in upper case
+type upperModerator struct{}
+
+func (upperModerator) Moderate(act *Activity, c Comment, s *Sender) Verdict {
+	return Verdict{Action: VerdictRewrite, Reason: "shouting", Comment: NewTextComment(strings.ToUpper(c.Content()), "red")}
+}
+
+func TestNewModerationChain(t *testing.T) {
+	_, err := NewModerationChain([]StageConfig{{Kind: "spam"}})
+	assert.NotNil(t, err)
+	_, err = NewModerationChain([]StageConfig{{Kind: "blocklist"}})
+	assert.NotNil(t, err)
+	_, err = NewModerationChain([]StageConfig{{Kind: "blocklist", Options: map[string]string{"words": "a", "action": "rewrite"}}})
+	assert.NotNil(t, err)
+	_, err = NewModerationChain([]StageConfig{{Kind: "ratelimit", Options: map[string]string{"count": "0", "window": "1s"}}})
+	assert.NotNil(t, err)
+	_, err = NewModerationChain([]StageConfig{{Kind: "dedupe"}, {Kind: "dedupe"}})
+	assert.NotNil(t, err)
+
+	configs := []StageConfig{
+		{Kind: "blocklist", Options: map[string]string{"words": "spam, scam"}},
+		{Kind: "dedupe"},
+	}
+	chain, err := NewModerationChain(configs)
+	assert.Nil(t, err)
+	assert.Equal(t, "blocklist#1", chain[0].Name)
+	assert.Equal(t, "dedupe#2", chain[1].Name)
+	assert.Equal(t, configs, chain.Configs())
+	assert.Contains(t, ModeratorKinds(), "ratelimit")
+}
+
+func TestRateLimitModerator(t *testing.T) {
+	now := time.Unix(1000, 0)
+	m, err := NewRateLimitModerator(map[string]string{"count": "2", "window": "10s"})
+	assert.Nil(t, err)
+	rl := m.(*RateLimitModerator)
+	rl.now = func() time.Time { return now }
+	a, b := &Sender{Addr: "10.0.0.1"}, &Sender{Addr: "10.0.0.2"}
+	c := NewTextComment("Hello", "red")
+
+	assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, a).Action)
+	assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, a).Action)
+	assert.Equal(t, VerdictThrottle, rl.Moderate(nil, c, a).Action)
+	assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, b).Action)
+	// clients with an id have their own limit whatever their address
+	assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, &Sender{Client: "c1", Addr: "10.0.0.1"}).Action)
+	// anonymous senders are not limited
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, &Sender{}).Action)
+	}
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, VerdictAllow, rl.Moderate(nil, c, a).Action)
+}
+
+func TestEngine_SetModeration(t *testing.T) {
+	RegisterModerator("upper", func(map[string]string) (Moderator, error) { return upperModerator{}, nil })
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	push := func(text string) *LabelComment {
+		lc, err := e.PushFrom(NewSender(act.CommentToken, "", "", "10.0.0.1:5000"), "text", map[string]string{"text": text, "color": "red"})
+		assert.Nil(t, err)
+		return lc
+	}
+
+	assert.Equal(t, DefaultModeration, act.Settings().Moderation.Configs())
+	assert.Equal(t, NotAuthorizedError, e.SetModeration(act.ReviewToken, act.Id, nil))
+	err := e.SetModeration(e.AdminToken, act.Id, []StageConfig{{Kind: "spam"}})
+	assert.Equal(t, http.StatusBadRequest, err.(*json.Error).Code)
+
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{
+		{Kind: "blocklist", Options: map[string]string{"words": "scam"}},
+		{Kind: "blocklist", Options: map[string]string{"words": "link", "action": "hold"}},
+		{Kind: "upper"},
+		{Kind: "ratelimit", Options: map[string]string{"count": "2", "window": "1m"}},
+	}))
+	entries := e.Audit.Query(&AuditFilter{Action: "SetModeration"})
+	assert.Equal(t, "blocklist,blocklist,upper,ratelimit", entries[len(entries)-1].Detail)
+
+	// denied comments are kept with the verdict of the stage that stopped the chain
+	denied := push("a SCAM")
+	assert.Equal(t, CommentStatusDenied, denied.Status)
+	assert.Equal(t, []Verdict{{Stage: "blocklist#1", Action: VerdictDeny, Reason: `blocked word "scam"`}}, denied.Verdicts)
+
+	// later stages see the rewritten comment, verdicts go to reviewers
+	held := push("click the link")
+	assert.Equal(t, "CLICK THE LINK", held.Content)
+	assert.True(t, held.Held())
+	rewritten := push("hello")
+	assert.Equal(t, "HELLO", rewritten.Content)
+	assert.False(t, rewritten.Held())
+	_, err = e.PushFrom(NewSender(act.CommentToken, "", "", "10.0.0.1:5000"), "text", map[string]string{"text": "hello", "color": "red"})
+	assert.Equal(t, RateLimitedError, err)
+	assert.Equal(t, 1, act.Stats().DeniedCount)
+	rcs, _ := e.Review(act.ReviewToken)
+	assert.Equal(t, []*LabelComment{held, rewritten}, rcs)
+	f := FlattenReviewComment(held)
+	assert.Equal(t, 2, len(f.Verdicts))
+	assert.Equal(t, "blocklist#2", f.Verdicts[0].Stage)
+	assert.Equal(t, VerdictRewrite, f.Verdicts[1].Action)
+	assert.Nil(t, FlattenComment(held).Verdicts)
+
+	// held comments wait for review even when review is off
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{{Kind: "blocklist", Options: map[string]string{"words": "link", "action": "hold"}}}))
+	e.ReviewOff(e.AdminToken, act.Id)
+	held = push("a link")
+	passed := push("fine")
+	assert.Equal(t, CommentStatusApproved, passed.Status)
+	assert.Equal(t, CommentStatusInitial, held.Status)
+	rcs, _ = e.Review(act.ReviewToken)
+	assert.Equal(t, []*LabelComment{held}, rcs)
+}
+
+func TestDanmakuService_PushRateLimit(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{
+		{Kind: "ratelimit", Options: map[string]string{"count": "2", "window": "1m"}},
+	}))
+	var created int
+	push := func(key string, client string, addr string) error {
+		args := &struct {
+			Token      string
+			Key        string
+			Client     string
+			Type       string
+			Attr       map[string]string
+			RemoteAddr string // set by RemoteAddrRPC
+		}{act.CommentToken, key, client, "text", map[string]string{"text": "hi " + key, "color": "red"}, addr}
+		reply := &struct {
+			Comment *FlatComment `json:"comment"`
+		}{}
+		err := s.Push(&Context{}, args, reply)
+		if err == nil {
+			created++
+		}
+		return err
+	}
+
+	// fresh keys do not lift the limit of an address; refused pushes are not kept
+	assert.Nil(t, push("a", "", "10.0.0.1:5000"))
+	assert.Nil(t, push("b", "", "10.0.0.1:5001"))
+	assert.Equal(t, RateLimitedError, push("c", "", "10.0.0.1:5002"))
+	assert.Nil(t, push("d", "", "10.0.0.2:5000"))
+	assert.Equal(t, 0, act.Stats().DeniedCount)
+	assert.Equal(t, 3, act.Stats().TotalCount)
+
+	// clients behind one address are limited each on their own, made up ids count as the address
+	c1, c2 := e.ClientId(""), e.ClientId("")
+	assert.Nil(t, push("e", c1, "10.0.0.1:5000"))
+	assert.Nil(t, push("f", c1, "10.0.0.1:5000"))
+	assert.Equal(t, RateLimitedError, push("g", c1, "10.0.0.1:5000"))
+	assert.Nil(t, push("h", c2, "10.0.0.1:5000"))
+	assert.Equal(t, RateLimitedError, push("i", "forged", "10.0.0.1:5000"))
+	rcs, _ := e.Review(act.ReviewToken)
+	assert.Equal(t, created, len(rcs))
+}
+
+func TestModerationChain_Panic(t *testing.T) {
+	RegisterModerator("panic", func(map[string]string) (Moderator, error) { return panicModerator{}, nil })
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	assert.Nil(t, e.SetDedupe(e.AdminToken, act.Id, DedupePolicy{Mode: DedupeCollapse, Window: time.Minute}))
+	assert.Nil(t, e.SetModeration(e.AdminToken, act.Id, []StageConfig{{Kind: "dedupe"}, {Kind: "panic"}}))
+	push := func() {
+		defer func() { recover() }()
+		e.Push(act.CommentToken, "text", map[string]string{"text": "panic", "color": "red"})
+	}
+
+	// the group held by the dedupe stage is released, the next push does not wait
+	push()
+	done := make(chan bool)
+	go func() {
+		push()
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("push waiting for a released group")
+	}
+}
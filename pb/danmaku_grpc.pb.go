@@ -0,0 +1,867 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+// gRPC mirror of the JSON-RPC DanmakuService. Every request carries the token
+// the JSON-RPC calls take; errors carry the engine error message with a gRPC code
+// matching its HTTP status.
+//
+// Regenerate with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative pb/danmaku.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pb/danmaku.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DanmakuService_Login_FullMethodName             = "/danmaku.DanmakuService/Login"
+	DanmakuService_NewActivity_FullMethodName       = "/danmaku.DanmakuService/NewActivity"
+	DanmakuService_Activities_FullMethodName        = "/danmaku.DanmakuService/Activities"
+	DanmakuService_DelActivity_FullMethodName       = "/danmaku.DanmakuService/DelActivity"
+	DanmakuService_RenameActivity_FullMethodName    = "/danmaku.DanmakuService/RenameActivity"
+	DanmakuService_ReviewOn_FullMethodName          = "/danmaku.DanmakuService/ReviewOn"
+	DanmakuService_ReviewOff_FullMethodName         = "/danmaku.DanmakuService/ReviewOff"
+	DanmakuService_GetActivityDigest_FullMethodName = "/danmaku.DanmakuService/GetActivityDigest"
+	DanmakuService_Reset_FullMethodName             = "/danmaku.DanmakuService/Reset"
+	DanmakuService_Push_FullMethodName              = "/danmaku.DanmakuService/Push"
+	DanmakuService_PushBatch_FullMethodName         = "/danmaku.DanmakuService/PushBatch"
+	DanmakuService_React_FullMethodName             = "/danmaku.DanmakuService/React"
+	DanmakuService_Review_FullMethodName            = "/danmaku.DanmakuService/Review"
+	DanmakuService_Approve_FullMethodName           = "/danmaku.DanmakuService/Approve"
+	DanmakuService_Deny_FullMethodName              = "/danmaku.DanmakuService/Deny"
+	DanmakuService_Decide_FullMethodName            = "/danmaku.DanmakuService/Decide"
+	DanmakuService_Retract_FullMethodName           = "/danmaku.DanmakuService/Retract"
+	DanmakuService_Display_FullMethodName           = "/danmaku.DanmakuService/Display"
+	DanmakuService_ReviewFeed_FullMethodName        = "/danmaku.DanmakuService/ReviewFeed"
+	DanmakuService_DisplayFeed_FullMethodName       = "/danmaku.DanmakuService/DisplayFeed"
+)
+
+// DanmakuServiceClient is the client API for DanmakuService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DanmakuServiceClient interface {
+	Login(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*LoginReply, error)
+	NewActivity(ctx context.Context, in *NewActivityRequest, opts ...grpc.CallOption) (*ActivityReply, error)
+	Activities(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*ActivitiesReply, error)
+	DelActivity(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error)
+	RenameActivity(ctx context.Context, in *RenameActivityRequest, opts ...grpc.CallOption) (*Empty, error)
+	ReviewOn(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error)
+	ReviewOff(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetActivityDigest(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*ActivityDigestReply, error)
+	Reset(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error)
+	Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*CommentReply, error)
+	PushBatch(ctx context.Context, in *PushBatchRequest, opts ...grpc.CallOption) (*PushBatchReply, error)
+	React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*Empty, error)
+	Review(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*ReviewReply, error)
+	Approve(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error)
+	Deny(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error)
+	// approve and deny at once
+	Decide(ctx context.Context, in *DecideRequest, opts ...grpc.CallOption) (*Empty, error)
+	Retract(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error)
+	Display(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*DisplayReply, error)
+	// comments waiting for review as they arrive; ends when the server shuts down
+	ReviewFeed(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReviewReply], error)
+	// comments to display and retracted ids as they arrive; ends when the server shuts down
+	DisplayFeed(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DisplayReply], error)
+}
+
+type danmakuServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDanmakuServiceClient(cc grpc.ClientConnInterface) DanmakuServiceClient {
+	return &danmakuServiceClient{cc}
+}
+
+func (c *danmakuServiceClient) Login(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*LoginReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginReply)
+	err := c.cc.Invoke(ctx, DanmakuService_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) NewActivity(ctx context.Context, in *NewActivityRequest, opts ...grpc.CallOption) (*ActivityReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActivityReply)
+	err := c.cc.Invoke(ctx, DanmakuService_NewActivity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Activities(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*ActivitiesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActivitiesReply)
+	err := c.cc.Invoke(ctx, DanmakuService_Activities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) DelActivity(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_DelActivity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) RenameActivity(ctx context.Context, in *RenameActivityRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_RenameActivity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) ReviewOn(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_ReviewOn_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) ReviewOff(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_ReviewOff_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) GetActivityDigest(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*ActivityDigestReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActivityDigestReply)
+	err := c.cc.Invoke(ctx, DanmakuService_GetActivityDigest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Reset(ctx context.Context, in *ActivityRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_Reset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*CommentReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommentReply)
+	err := c.cc.Invoke(ctx, DanmakuService_Push_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) PushBatch(ctx context.Context, in *PushBatchRequest, opts ...grpc.CallOption) (*PushBatchReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PushBatchReply)
+	err := c.cc.Invoke(ctx, DanmakuService_PushBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_React_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Review(ctx context.Context, in *ReviewRequest, opts ...grpc.CallOption) (*ReviewReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReviewReply)
+	err := c.cc.Invoke(ctx, DanmakuService_Review_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Approve(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_Approve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Deny(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_Deny_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Decide(ctx context.Context, in *DecideRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_Decide_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Retract(ctx context.Context, in *IdsRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, DanmakuService_Retract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) Display(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (*DisplayReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DisplayReply)
+	err := c.cc.Invoke(ctx, DanmakuService_Display_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *danmakuServiceClient) ReviewFeed(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReviewReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DanmakuService_ServiceDesc.Streams[0], DanmakuService_ReviewFeed_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TokenRequest, ReviewReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DanmakuService_ReviewFeedClient = grpc.ServerStreamingClient[ReviewReply]
+
+func (c *danmakuServiceClient) DisplayFeed(ctx context.Context, in *TokenRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DisplayReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &DanmakuService_ServiceDesc.Streams[1], DanmakuService_DisplayFeed_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TokenRequest, DisplayReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DanmakuService_DisplayFeedClient = grpc.ServerStreamingClient[DisplayReply]
+
+// DanmakuServiceServer is the server API for DanmakuService service.
+// All implementations must embed UnimplementedDanmakuServiceServer
+// for forward compatibility.
+type DanmakuServiceServer interface {
+	Login(context.Context, *TokenRequest) (*LoginReply, error)
+	NewActivity(context.Context, *NewActivityRequest) (*ActivityReply, error)
+	Activities(context.Context, *TokenRequest) (*ActivitiesReply, error)
+	DelActivity(context.Context, *ActivityRequest) (*Empty, error)
+	RenameActivity(context.Context, *RenameActivityRequest) (*Empty, error)
+	ReviewOn(context.Context, *ActivityRequest) (*Empty, error)
+	ReviewOff(context.Context, *ActivityRequest) (*Empty, error)
+	GetActivityDigest(context.Context, *TokenRequest) (*ActivityDigestReply, error)
+	Reset(context.Context, *ActivityRequest) (*Empty, error)
+	Push(context.Context, *PushRequest) (*CommentReply, error)
+	PushBatch(context.Context, *PushBatchRequest) (*PushBatchReply, error)
+	React(context.Context, *ReactRequest) (*Empty, error)
+	Review(context.Context, *ReviewRequest) (*ReviewReply, error)
+	Approve(context.Context, *IdsRequest) (*Empty, error)
+	Deny(context.Context, *IdsRequest) (*Empty, error)
+	// approve and deny at once
+	Decide(context.Context, *DecideRequest) (*Empty, error)
+	Retract(context.Context, *IdsRequest) (*Empty, error)
+	Display(context.Context, *TokenRequest) (*DisplayReply, error)
+	// comments waiting for review as they arrive; ends when the server shuts down
+	ReviewFeed(*TokenRequest, grpc.ServerStreamingServer[ReviewReply]) error
+	// comments to display and retracted ids as they arrive; ends when the server shuts down
+	DisplayFeed(*TokenRequest, grpc.ServerStreamingServer[DisplayReply]) error
+	mustEmbedUnimplementedDanmakuServiceServer()
+}
+
+// UnimplementedDanmakuServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDanmakuServiceServer struct{}
+
+func (UnimplementedDanmakuServiceServer) Login(context.Context, *TokenRequest) (*LoginReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedDanmakuServiceServer) NewActivity(context.Context, *NewActivityRequest) (*ActivityReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewActivity not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Activities(context.Context, *TokenRequest) (*ActivitiesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Activities not implemented")
+}
+func (UnimplementedDanmakuServiceServer) DelActivity(context.Context, *ActivityRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DelActivity not implemented")
+}
+func (UnimplementedDanmakuServiceServer) RenameActivity(context.Context, *RenameActivityRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameActivity not implemented")
+}
+func (UnimplementedDanmakuServiceServer) ReviewOn(context.Context, *ActivityRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReviewOn not implemented")
+}
+func (UnimplementedDanmakuServiceServer) ReviewOff(context.Context, *ActivityRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReviewOff not implemented")
+}
+func (UnimplementedDanmakuServiceServer) GetActivityDigest(context.Context, *TokenRequest) (*ActivityDigestReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActivityDigest not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Reset(context.Context, *ActivityRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reset not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Push(context.Context, *PushRequest) (*CommentReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
+}
+func (UnimplementedDanmakuServiceServer) PushBatch(context.Context, *PushBatchRequest) (*PushBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushBatch not implemented")
+}
+func (UnimplementedDanmakuServiceServer) React(context.Context, *ReactRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method React not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Review(context.Context, *ReviewRequest) (*ReviewReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Review not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Approve(context.Context, *IdsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Approve not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Deny(context.Context, *IdsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deny not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Decide(context.Context, *DecideRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decide not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Retract(context.Context, *IdsRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Retract not implemented")
+}
+func (UnimplementedDanmakuServiceServer) Display(context.Context, *TokenRequest) (*DisplayReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Display not implemented")
+}
+func (UnimplementedDanmakuServiceServer) ReviewFeed(*TokenRequest, grpc.ServerStreamingServer[ReviewReply]) error {
+	return status.Errorf(codes.Unimplemented, "method ReviewFeed not implemented")
+}
+func (UnimplementedDanmakuServiceServer) DisplayFeed(*TokenRequest, grpc.ServerStreamingServer[DisplayReply]) error {
+	return status.Errorf(codes.Unimplemented, "method DisplayFeed not implemented")
+}
+func (UnimplementedDanmakuServiceServer) mustEmbedUnimplementedDanmakuServiceServer() {}
+func (UnimplementedDanmakuServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeDanmakuServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DanmakuServiceServer will
+// result in compilation errors.
+type UnsafeDanmakuServiceServer interface {
+	mustEmbedUnimplementedDanmakuServiceServer()
+}
+
+func RegisterDanmakuServiceServer(s grpc.ServiceRegistrar, srv DanmakuServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDanmakuServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DanmakuService_ServiceDesc, srv)
+}
+
+func _DanmakuService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Login(ctx, req.(*TokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_NewActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).NewActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_NewActivity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).NewActivity(ctx, req.(*NewActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Activities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Activities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Activities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Activities(ctx, req.(*TokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_DelActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).DelActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_DelActivity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).DelActivity(ctx, req.(*ActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_RenameActivity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).RenameActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_RenameActivity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).RenameActivity(ctx, req.(*RenameActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_ReviewOn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).ReviewOn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_ReviewOn_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).ReviewOn(ctx, req.(*ActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_ReviewOff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).ReviewOff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_ReviewOff_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).ReviewOff(ctx, req.(*ActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_GetActivityDigest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).GetActivityDigest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_GetActivityDigest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).GetActivityDigest(ctx, req.(*TokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Reset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Reset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Reset(ctx, req.(*ActivityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Push_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Push(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Push_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Push(ctx, req.(*PushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_PushBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).PushBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_PushBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).PushBatch(ctx, req.(*PushBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_React_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).React(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_React_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).React(ctx, req.(*ReactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Review_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Review(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Review_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Review(ctx, req.(*ReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Approve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Approve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Approve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Approve(ctx, req.(*IdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Deny_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Deny(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Deny_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Deny(ctx, req.(*IdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Decide_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecideRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Decide(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Decide_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Decide(ctx, req.(*DecideRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Retract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Retract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Retract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Retract(ctx, req.(*IdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_Display_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DanmakuServiceServer).Display(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DanmakuService_Display_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DanmakuServiceServer).Display(ctx, req.(*TokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DanmakuService_ReviewFeed_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TokenRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DanmakuServiceServer).ReviewFeed(m, &grpc.GenericServerStream[TokenRequest, ReviewReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DanmakuService_ReviewFeedServer = grpc.ServerStreamingServer[ReviewReply]
+
+func _DanmakuService_DisplayFeed_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TokenRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DanmakuServiceServer).DisplayFeed(m, &grpc.GenericServerStream[TokenRequest, DisplayReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type DanmakuService_DisplayFeedServer = grpc.ServerStreamingServer[DisplayReply]
+
+// DanmakuService_ServiceDesc is the grpc.ServiceDesc for DanmakuService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DanmakuService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "danmaku.DanmakuService",
+	HandlerType: (*DanmakuServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _DanmakuService_Login_Handler,
+		},
+		{
+			MethodName: "NewActivity",
+			Handler:    _DanmakuService_NewActivity_Handler,
+		},
+		{
+			MethodName: "Activities",
+			Handler:    _DanmakuService_Activities_Handler,
+		},
+		{
+			MethodName: "DelActivity",
+			Handler:    _DanmakuService_DelActivity_Handler,
+		},
+		{
+			MethodName: "RenameActivity",
+			Handler:    _DanmakuService_RenameActivity_Handler,
+		},
+		{
+			MethodName: "ReviewOn",
+			Handler:    _DanmakuService_ReviewOn_Handler,
+		},
+		{
+			MethodName: "ReviewOff",
+			Handler:    _DanmakuService_ReviewOff_Handler,
+		},
+		{
+			MethodName: "GetActivityDigest",
+			Handler:    _DanmakuService_GetActivityDigest_Handler,
+		},
+		{
+			MethodName: "Reset",
+			Handler:    _DanmakuService_Reset_Handler,
+		},
+		{
+			MethodName: "Push",
+			Handler:    _DanmakuService_Push_Handler,
+		},
+		{
+			MethodName: "PushBatch",
+			Handler:    _DanmakuService_PushBatch_Handler,
+		},
+		{
+			MethodName: "React",
+			Handler:    _DanmakuService_React_Handler,
+		},
+		{
+			MethodName: "Review",
+			Handler:    _DanmakuService_Review_Handler,
+		},
+		{
+			MethodName: "Approve",
+			Handler:    _DanmakuService_Approve_Handler,
+		},
+		{
+			MethodName: "Deny",
+			Handler:    _DanmakuService_Deny_Handler,
+		},
+		{
+			MethodName: "Decide",
+			Handler:    _DanmakuService_Decide_Handler,
+		},
+		{
+			MethodName: "Retract",
+			Handler:    _DanmakuService_Retract_Handler,
+		},
+		{
+			MethodName: "Display",
+			Handler:    _DanmakuService_Display_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReviewFeed",
+			Handler:       _DanmakuService_ReviewFeed_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DisplayFeed",
+			Handler:       _DanmakuService_DisplayFeed_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pb/danmaku.proto",
+}
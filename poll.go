@@ -0,0 +1,141 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	PollMinOptions = 2
+	PollMaxOptions = 10
+)
+
+var (
+	PollClosedError   = &json.Error{Code: http.StatusForbidden, Message: "poll closed"}
+	AlreadyVotedError = &json.Error{Code: http.StatusConflict, Message: "already voted"}
+)
+
+// poll with options and a tally per option
+type Poll struct {
+	Id       int
+	Question string
+	Options  []string
+	Open     bool
+	Tally    []int
+	voters   map[string]bool
+}
+
+func NewPollBoard() *PollBoard {
+	return &PollBoard{
+		PollMap: make(map[int]*Poll),
+	}
+}
+
+// PollBoard holds the polls of an activity
+type PollBoard struct {
+	mutex   sync.Mutex
+	PollMap map[int]*Poll
+	IdCount int
+}
+
+// normalize question and options of a poll
+func NormalizePoll(question string, options []string) (string, []string, bool) {
+	question = NormalizeText(question)
+	if n := TextLength(question); n == 0 || n > TextDefaultMaxLength {
+		return "", nil, false
+	}
+	if len(options) < PollMinOptions || len(options) > PollMaxOptions {
+		return "", nil, false
+	}
+	r := make([]string, 0, len(options))
+	for _, o := range options {
+		o = NormalizeText(o)
+		if n := TextLength(o); n == 0 || n > TextDefaultMaxLength || containsString(r, o) {
+			return "", nil, false
+		}
+		r = append(r, o)
+	}
+	return question, r, true
+}
+
+// add a closed poll with an unique id
+func (b *PollBoard) Add(question string, options []string) *Poll {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.IdCount++
+	p := &Poll{
+		Id:       b.IdCount,
+		Question: question,
+		Options:  options,
+		Tally:    make([]int, len(options)),
+		voters:   make(map[string]bool),
+	}
+	b.PollMap[p.Id] = p
+	return p
+}
+
+// open or close a poll
+func (b *PollBoard) SetOpen(id int, open bool) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	p, ok := b.PollMap[id]
+	if !ok {
+		return false
+	}
+	p.Open = open
+	return true
+}
+
+// vote for an option; each voter votes once per poll
+func (b *PollBoard) Vote(id int, voter string, option int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	p, ok := b.PollMap[id]
+	if !ok {
+		return NotExistError
+	}
+	if !p.Open {
+		return PollClosedError
+	}
+	if voter == "" || option < 0 || option >= len(p.Options) {
+		return IllFormatError
+	}
+	if p.voters[voter] {
+		return AlreadyVotedError
+	}
+	p.voters[voter] = true
+	p.Tally[option]++
+	return nil
+}
+
+// snapshot of all polls ordered by id
+func (b *PollBoard) Polls() []*Poll {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	r := make([]*Poll, 0, len(b.PollMap))
+	for _, p := range b.PollMap {
+		c := *p
+		c.Tally = append([]int(nil), p.Tally...)
+		r = append(r, &c)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].Id < r[j].Id })
+	return r
+}
+
+// remove all polls
+func (b *PollBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.IdCount = 0
+	b.PollMap = make(map[int]*Poll)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizePoll(t *testing.T) {
+	q, opts, ok := NormalizePoll("  Best  talk? ", []string{" first ", "second"})
+	assert.True(t, ok)
+	assert.Equal(t, "Best talk?", q)
+	assert.Equal(t, []string{"first", "second"}, opts)
+
+	_, _, ok = NormalizePoll("", []string{"a", "b"})
+	assert.False(t, ok)
+	_, _, ok = NormalizePoll("q", []string{"a"})
+	assert.False(t, ok)
+	_, _, ok = NormalizePoll("q", []string{"a", " a"})
+	assert.False(t, ok)
+	_, _, ok = NormalizePoll("q", []string{"a", "\u200b"})
+	assert.False(t, ok)
+}
+
+func TestPollBoard(t *testing.T) {
+	b := NewPollBoard()
+	p := b.Add("q", []string{"a", "b"})
+	assert.Equal(t, 1, p.Id)
+	assert.False(t, p.Open)
+
+	assert.Equal(t, PollClosedError, b.Vote(p.Id, "v1", 0))
+	assert.True(t, b.SetOpen(p.Id, true))
+	assert.False(t, b.SetOpen(p.Id+1, true))
+
+	assert.Nil(t, b.Vote(p.Id, "v1", 0))
+	assert.Nil(t, b.Vote(p.Id, "v2", 1))
+	assert.Nil(t, b.Vote(p.Id, "v3", 1))
+	assert.Equal(t, AlreadyVotedError, b.Vote(p.Id, "v1", 1))
+	assert.Equal(t, IllFormatError, b.Vote(p.Id, "v4", 2))
+	assert.Equal(t, IllFormatError, b.Vote(p.Id, "", 0))
+	assert.Equal(t, NotExistError, b.Vote(p.Id+1, "v1", 0))
+
+	ps := b.Polls()
+	assert.Equal(t, 1, len(ps))
+	assert.Equal(t, []int{1, 2}, ps[0].Tally)
+
+	b.SetOpen(p.Id, false)
+	assert.Equal(t, PollClosedError, b.Vote(p.Id, "v4", 0))
+
+	// snapshots do not change with later votes
+	b.SetOpen(p.Id, true)
+	b.Vote(p.Id, "v4", 0)
+	assert.Equal(t, []int{1, 2}, ps[0].Tally)
+
+	b.Reset()
+	assert.Equal(t, 0, len(b.Polls()))
+}
+
+func TestDanmakuService_Vote(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	p, _ := e.NewPoll(e.AdminToken, act.Id, "Best talk?", []string{"first", "second"})
+	e.SetPollOpen(e.AdminToken, act.Id, p.Id, true)
+	vote := func(client string, option int) error {
+		return s.Vote(&Context{}, &struct {
+			Token  string
+			Client string
+			PollId int
+			Option int
+		}{act.CommentToken, client, p.Id, option}, &struct{}{})
+	}
+
+	// voters are told apart by the client id issued on login, not by address
+	v1, v2 := e.ClientId(""), e.ClientId("")
+	assert.Nil(t, vote(v1, 0))
+	assert.Equal(t, AlreadyVotedError, vote(v1, 1))
+	assert.Nil(t, vote(v2, 1))
+	assert.Equal(t, InvalidClientError, vote("", 1))
+	assert.Equal(t, InvalidClientError, vote("10.0.0.3", 1))
+	ps, _ := e.Polls(act.CommentToken)
+	assert.Equal(t, []int{1, 1}, ps[0].Tally)
+}
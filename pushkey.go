@@ -0,0 +1,126 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	PushKeyMaxLength = 64
+	PushKeyMaxCount  = 10000
+)
+
+var (
+	// how long a push key is remembered, set by configuration
+	PushKeyDefaultWindow = 10 * time.Minute
+
+	BadPushKeyError = &json.Error{Code: http.StatusBadRequest, Message: "push key too long"}
+)
+
+// a key of a sender; senders choose their keys, so the same key of two senders is two keys
+type pushKeyId struct {
+	sender string
+	key    string
+}
+
+// a key and the comment pushed with it; done is closed once the push finished
+type pushKey struct {
+	id   pushKeyId
+	at   time.Time
+	done chan struct{}
+	lc   *LabelComment
+}
+
+func NewPushKeyBoard() *PushKeyBoard {
+	return &PushKeyBoard{
+		Window: PushKeyDefaultWindow,
+		keys:   make(map[pushKeyId]*pushKey),
+		now:    time.Now,
+	}
+}
+
+// PushKeyBoard remembers the comments pushed with client keys for a window, so that retried
+// pushes get the original comment back; keys are told apart per sender, at most PushKeyMaxCount
+// are kept, oldest go first
+type PushKeyBoard struct {
+	mutex  sync.Mutex
+	Window time.Duration
+	keys   map[pushKeyId]*pushKey
+	order  []*pushKey
+	now    func() time.Time
+}
+
+// forget keys past the window or beyond the maximum; must hold the mutex
+func (b *PushKeyBoard) expire() {
+	now := b.now()
+	for len(b.order) > 0 {
+		k := b.order[0]
+		if now.Sub(k.at) < b.Window && len(b.order) <= PushKeyMaxCount {
+			return
+		}
+		b.order[0] = nil
+		b.order = b.order[1:]
+		if b.keys[k.id] == k {
+			delete(b.keys, k.id)
+		}
+	}
+}
+
+// push once per key of a sender: the first call runs push, later calls of the sender with the
+// same key get its comment and replayed set, waiting for it if still running. A failed push
+// is not remembered
+func (b *PushKeyBoard) Do(sender string, key string, push func() (*LabelComment, error)) (lc *LabelComment, replayed bool, err error) {
+	id := pushKeyId{sender: sender, key: key}
+	for {
+		b.mutex.Lock()
+		b.expire()
+		k, ok := b.keys[id]
+		if !ok {
+			k = &pushKey{id: id, at: b.now(), done: make(chan struct{})}
+			b.keys[id] = k
+			b.order = append(b.order, k)
+		}
+		b.mutex.Unlock()
+
+		if ok {
+			<-k.done
+			if k.lc != nil {
+				return k.lc, true, nil
+			}
+			// the first push failed and forgot the key, try again
+			continue
+		}
+
+		lc, err = push()
+		b.mutex.Lock()
+		if err != nil && b.keys[id] == k {
+			delete(b.keys, id)
+		}
+		k.lc = lc
+		b.mutex.Unlock()
+		close(k.done)
+		return lc, false, err
+	}
+}
+
+// number of remembered keys
+func (b *PushKeyBoard) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.expire()
+	return len(b.keys)
+}
+
+func (b *PushKeyBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.keys = make(map[pushKeyId]*pushKey)
+	b.order = nil
+}
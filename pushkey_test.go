@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPushKeyBoard(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := NewPushKeyBoard()
+	b.now = func() time.Time { return now }
+	pushes := 0
+	push := func() (*LabelComment, error) {
+		pushes++
+		return &LabelComment{Id: pushes}, nil
+	}
+
+	lc, replayed, err := b.Do("10.0.0.1", "a", push)
+	assert.Nil(t, err)
+	assert.False(t, replayed)
+	again, replayed, _ := b.Do("10.0.0.1", "a", push)
+	assert.True(t, replayed)
+	assert.Same(t, lc, again)
+	b.Do("10.0.0.1", "b", push)
+	assert.Equal(t, 2, pushes)
+
+	// another sender with the same key pushes its own comment
+	other, replayed, _ := b.Do("10.0.0.2", "a", push)
+	assert.False(t, replayed)
+	assert.NotSame(t, lc, other)
+
+	// failed pushes are not remembered
+	_, _, err = b.Do("10.0.0.1", "c", func() (*LabelComment, error) { return nil, QueueFullError })
+	assert.Equal(t, QueueFullError, err)
+	_, replayed, _ = b.Do("10.0.0.1", "c", push)
+	assert.False(t, replayed)
+	assert.Equal(t, 4, pushes)
+
+	// keys are forgotten after the window
+	now = now.Add(b.Window)
+	_, replayed, _ = b.Do("10.0.0.1", "a", push)
+	assert.False(t, replayed)
+	assert.Equal(t, 1, b.Len())
+
+	b.Reset()
+	assert.Equal(t, 0, b.Len())
+}
+
+func TestPushKeyBoard_Concurrent(t *testing.T) {
+	b := NewPushKeyBoard()
+	release := make(chan struct{})
+	var mutex sync.Mutex
+	pushes := 0
+	push := func() (*LabelComment, error) {
+		<-release
+		mutex.Lock()
+		defer mutex.Unlock()
+		pushes++
+		if pushes == 1 {
+			return nil, errors.New("flaky")
+		}
+		return &LabelComment{Id: pushes}, nil
+	}
+
+	// retries racing the first push wait for it, and push themselves once it failed
+	var wg sync.WaitGroup
+	lcs := make([]*LabelComment, 4)
+	for i := range lcs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lcs[i], _, _ = b.Do("10.0.0.1", "k", push)
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, 2, pushes)
+	for _, lc := range lcs {
+		if lc != nil {
+			assert.Equal(t, 2, lc.Id)
+		}
+	}
+}
+
+func TestPushKeyBoard_MaxCount(t *testing.T) {
+	b := NewPushKeyBoard()
+	push := func() (*LabelComment, error) { return &LabelComment{}, nil }
+	for i := 0; i < PushKeyMaxCount+10; i++ {
+		b.Do("10.0.0.1", string(rune(i)), push)
+	}
+	assert.Equal(t, PushKeyMaxCount, b.Len())
+}
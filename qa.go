@@ -0,0 +1,151 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	ActivityModeDanmaku = "danmaku"
+	ActivityModeQA      = "qa"
+)
+
+const (
+	QuestionStateOpen      = "open"
+	QuestionStateAnswering = "answering"
+	QuestionStateAnswered  = "answered"
+)
+
+var (
+	ActivityModes  = []string{ActivityModeDanmaku, ActivityModeQA}
+	QuestionStates = []string{QuestionStateOpen, QuestionStateAnswering, QuestionStateAnswered}
+
+	QuestionAnsweredError = &json.Error{Code: http.StatusForbidden, Message: "question answered"}
+)
+
+// approved comment asked as a question
+type Question struct {
+	Comment *LabelComment
+	Votes   int
+	State   string
+	voters  map[string]bool
+}
+
+func NewQuestionBoard() *QuestionBoard {
+	return &QuestionBoard{
+		QuestionMap: make(map[int]*Question),
+	}
+}
+
+// QuestionBoard ranks approved questions of a Q&A activity
+type QuestionBoard struct {
+	mutex       sync.Mutex
+	QuestionMap map[int]*Question
+}
+
+// add approved comments as open questions, keyed by comment id
+func (b *QuestionBoard) Add(lcs []*LabelComment) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, c := range lcs {
+		if _, ok := b.QuestionMap[c.Id]; ok {
+			continue
+		}
+		b.QuestionMap[c.Id] = &Question{Comment: c, State: QuestionStateOpen, voters: make(map[string]bool)}
+	}
+}
+
+// remove questions by comment ids
+func (b *QuestionBoard) Remove(ids []int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, id := range ids {
+		delete(b.QuestionMap, id)
+	}
+}
+
+// upvote a question that is not answered yet; each voter votes once per question
+func (b *QuestionBoard) Upvote(id int, voter string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	q, ok := b.QuestionMap[id]
+	if !ok {
+		return NotExistError
+	}
+	if voter == "" {
+		return IllFormatError
+	}
+	if q.State == QuestionStateAnswered {
+		return QuestionAnsweredError
+	}
+	if q.voters[voter] {
+		return AlreadyVotedError
+	}
+	q.voters[voter] = true
+	q.Votes++
+	return nil
+}
+
+// change the state of a question; only one question is answering at a time,
+// the previous one is marked answered
+func (b *QuestionBoard) Mark(id int, state string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	q, ok := b.QuestionMap[id]
+	if !ok {
+		return NotExistError
+	}
+	if !containsString(QuestionStates, state) {
+		return IllFormatError
+	}
+	if state == QuestionStateAnswering {
+		for _, p := range b.QuestionMap {
+			if p.State == QuestionStateAnswering && p != q {
+				p.State = QuestionStateAnswered
+			}
+		}
+	}
+	q.State = state
+	return nil
+}
+
+// snapshot of questions ranked by state, then votes, then arrival;
+// the answering question comes first and answered ones last
+func (b *QuestionBoard) Ranked() []*Question {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	r := make([]*Question, 0, len(b.QuestionMap))
+	for _, q := range b.QuestionMap {
+		c := *q
+		r = append(r, &c)
+	}
+	rank := map[string]int{QuestionStateAnswering: 0, QuestionStateOpen: 1, QuestionStateAnswered: 2}
+	sort.Slice(r, func(i, j int) bool {
+		if rank[r[i].State] != rank[r[j].State] {
+			return rank[r[i].State] < rank[r[j].State]
+		}
+		if r[i].Votes != r[j].Votes {
+			return r[i].Votes > r[j].Votes
+		}
+		return r[i].Comment.Id < r[j].Comment.Id
+	})
+	return r
+}
+
+// remove all questions
+func (b *QuestionBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.QuestionMap = make(map[int]*Question)
+}
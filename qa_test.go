@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQuestionBoard(t *testing.T) {
+	b := NewQuestionBoard()
+	c1 := &LabelComment{Id: 1, Content: "first"}
+	c2 := &LabelComment{Id: 2, Content: "second"}
+	c3 := &LabelComment{Id: 3, Content: "third"}
+	b.Add([]*LabelComment{c1, c2, c3})
+
+	assert.Nil(t, b.Upvote(3, "v1"))
+	assert.Nil(t, b.Upvote(3, "v2"))
+	assert.Nil(t, b.Upvote(2, "v1"))
+	assert.Equal(t, AlreadyVotedError, b.Upvote(3, "v1"))
+	assert.Equal(t, NotExistError, b.Upvote(4, "v1"))
+	assert.Equal(t, IllFormatError, b.Upvote(1, ""))
+
+	ids := func() (r []int) {
+		for _, q := range b.Ranked() {
+			r = append(r, q.Comment.Id)
+		}
+		return
+	}
+	assert.Equal(t, []int{3, 2, 1}, ids())
+
+	assert.Nil(t, b.Mark(1, QuestionStateAnswering))
+	assert.Equal(t, []int{1, 3, 2}, ids())
+
+	// a new answering question ends the previous one
+	assert.Nil(t, b.Mark(3, QuestionStateAnswering))
+	assert.Equal(t, []int{3, 2, 1}, ids())
+	assert.Equal(t, QuestionStateAnswered, b.QuestionMap[1].State)
+	assert.Equal(t, QuestionAnsweredError, b.Upvote(1, "v3"))
+
+	assert.Equal(t, IllFormatError, b.Mark(2, "skipped"))
+	assert.Equal(t, NotExistError, b.Mark(4, QuestionStateOpen))
+
+	b.Remove([]int{3})
+	assert.Equal(t, []int{2, 1}, ids())
+
+	b.Reset()
+	assert.Equal(t, 0, len(b.Ranked()))
+}
+
+func TestDanmakuService_Upvote(t *testing.T) {
+	e := NewEngine()
+	s := &DanmakuService{E: e}
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	e.SetMode(e.AdminToken, act.Id, ActivityModeQA)
+	lc, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Why?", "color": "red"})
+	e.Review(act.ReviewToken)
+	e.Approve(act.ReviewToken, []int{lc.Id})
+	upvote := func(client string) error {
+		return s.Upvote(&Context{}, &struct {
+			Token  string
+			Client string
+			Id     int
+		}{act.CommentToken, client, lc.Id}, &struct{}{})
+	}
+
+	// voters are told apart by the client id issued on login, not by address
+	v1, v2 := e.ClientId(""), e.ClientId("")
+	assert.Nil(t, upvote(v1))
+	assert.Equal(t, AlreadyVotedError, upvote(v1))
+	assert.Nil(t, upvote(v2))
+	assert.Equal(t, InvalidClientError, upvote(""))
+	assert.Equal(t, InvalidClientError, upvote("10.0.0.3"))
+	qs, _ := e.Questions(act.CommentToken)
+	assert.Equal(t, 2, qs[0].Votes)
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// shards of the comment index, so that pushes and lookups rarely wait on each other
+const commentShards = 16
+
+// FIFO ring buffer of comments, reusing its storage between drains; not safe for concurrent use
+type commentRing struct {
+	buf  []*LabelComment
+	head int
+	n    int
+}
+
+func (q *commentRing) Len() int {
+	return q.n
+}
+
+// copy the comments in order to dst, which must be large enough
+func (q *commentRing) copyTo(dst []*LabelComment) {
+	k := copy(dst, q.buf[q.head:min(q.head+q.n, len(q.buf))])
+	copy(dst[k:], q.buf[:q.n-k])
+}
+
+func (q *commentRing) at(i int) **LabelComment {
+	return &q.buf[(q.head+i)%len(q.buf)]
+}
+
+func (q *commentRing) push(c *LabelComment) {
+	if q.n == len(q.buf) {
+		buf := make([]*LabelComment, max(2*len(q.buf), QueueDefaultLength, 1))
+		q.copyTo(buf)
+		q.buf, q.head = buf, 0
+	}
+	*q.at(q.n) = c
+	q.n++
+}
+
+func (q *commentRing) popFront() *LabelComment {
+	c := q.buf[q.head]
+	q.buf[q.head] = nil
+	q.head = (q.head + 1) % len(q.buf)
+	q.n--
+	return c
+}
+
+func (q *commentRing) front() *LabelComment {
+	return q.buf[q.head]
+}
+
+// take all comments in order, nil when empty; storage grown by a burst is released
+func (q *commentRing) drain() []*LabelComment {
+	if q.n == 0 {
+		return nil
+	}
+	r := q.items()
+	if len(q.buf) > 4*QueueDefaultLength {
+		q.buf = nil
+	} else {
+		clear(q.buf)
+	}
+	q.head, q.n = 0, 0
+	return r
+}
+
+// put comments in front of the others, in order
+func (q *commentRing) prepend(cs []*LabelComment) {
+	rest := q.drain()
+	for _, c := range cs {
+		q.push(c)
+	}
+	for _, c := range rest {
+		q.push(c)
+	}
+}
+
+// copy of the comments in order
+func (q *commentRing) items() []*LabelComment {
+	r := make([]*LabelComment, q.n)
+	q.copyTo(r)
+	return r
+}
+
+// remove a comment, keeping the order of the others
+func (q *commentRing) remove(c *LabelComment) bool {
+	for i := 0; i < q.n; i++ {
+		if *q.at(i) != c {
+			continue
+		}
+		for j := i; j < q.n-1; j++ {
+			*q.at(j) = *q.at(j + 1)
+		}
+		*q.at(q.n - 1) = nil
+		q.n--
+		return true
+	}
+	return false
+}
+
+// lock-free intake of pushed comments: any number of pushers, one taker at a time
+type commentIntake struct {
+	top atomic.Pointer[LabelComment]
+}
+
+func (in *commentIntake) push(c *LabelComment) {
+	for {
+		top := in.top.Load()
+		c.next = top
+		if in.top.CompareAndSwap(top, c) {
+			return
+		}
+	}
+}
+
+// hand everything pushed so far to f, oldest first
+func (in *commentIntake) take(f func(c *LabelComment)) {
+	var prev *LabelComment
+	for c := in.top.Swap(nil); c != nil; {
+		next := c.next
+		c.next = prev
+		prev, c = c, next
+	}
+	for c := prev; c != nil; {
+		next := c.next
+		c.next = nil
+		f(c)
+		c = next
+	}
+}
+
+// comments by id, sharded by id
+type commentIndex struct {
+	shards [commentShards]commentShard
+	n      atomic.Int64
+}
+
+type commentShard struct {
+	sync.RWMutex
+	m map[int]*LabelComment
+	_ [32]byte // keep shards on separate cache lines
+}
+
+func (x *commentIndex) shard(id int) *commentShard {
+	return &x.shards[uint(id)%commentShards]
+}
+
+func (x *commentIndex) get(id int) (*LabelComment, bool) {
+	s := x.shard(id)
+	s.RLock()
+	defer s.RUnlock()
+
+	c, ok := s.m[id]
+	return c, ok
+}
+
+func (x *commentIndex) put(c *LabelComment) {
+	s := x.shard(c.Id)
+	s.Lock()
+	defer s.Unlock()
+
+	if s.m == nil {
+		s.m = make(map[int]*LabelComment)
+	}
+	s.m[c.Id] = c
+	x.n.Add(1)
+}
+
+func (x *commentIndex) del(id int) {
+	s := x.shard(id)
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.m[id]; ok {
+		delete(s.m, id)
+		x.n.Add(-1)
+	}
+}
+
+func (x *commentIndex) Len() int {
+	return int(x.n.Load())
+}
+
+func (x *commentIndex) reset() {
+	for i := range x.shards {
+		s := &x.shards[i]
+		s.Lock()
+		s.m = nil
+		s.Unlock()
+	}
+	x.n.Store(0)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentRing(t *testing.T) {
+	defer func(n int) { QueueDefaultLength = n }(QueueDefaultLength)
+	QueueDefaultLength = 2
+
+	var q commentRing
+	cs := make([]*LabelComment, 6)
+	for i := range cs {
+		cs[i] = &LabelComment{Id: i}
+	}
+	assert.Nil(t, q.drain())
+
+	// wrap around, then grow
+	q.push(cs[0])
+	q.push(cs[1])
+	assert.Equal(t, cs[0], q.popFront())
+	q.push(cs[2])
+	q.push(cs[3])
+	q.push(cs[4])
+	assert.Equal(t, 4, q.Len())
+	assert.Equal(t, cs[1], q.front())
+	assert.True(t, q.remove(cs[3]))
+	assert.False(t, q.remove(cs[5]))
+	assert.Equal(t, []*LabelComment{cs[1], cs[2], cs[4]}, q.drain())
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestCommentIntake(t *testing.T) {
+	var in commentIntake
+	var wg sync.WaitGroup
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				in.push(&LabelComment{Id: p*1000 + i})
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	// each pusher's comments come out in the order pushed
+	last := map[int]int{0: -1, 1: -1, 2: -1, 3: -1}
+	n := 0
+	in.take(func(c *LabelComment) {
+		assert.Nil(t, c.next)
+		p, i := c.Id/1000, c.Id%1000
+		assert.Equal(t, last[p]+1, i)
+		last[p] = i
+		n++
+	})
+	assert.Equal(t, 400, n)
+	in.take(func(*LabelComment) { t.Fatal("intake not empty") })
+}
+
+// the queues as they were before the intake and ring buffers, to benchmark against:
+// a single mutex around a map and slices reallocated on every poll
+type mutexActivity struct {
+	mutex         sync.Mutex
+	commentMap    map[int]*LabelComment
+	initialQueue  []*LabelComment
+	approvedQueue []*LabelComment
+	totalCount    int
+}
+
+func (act *mutexActivity) Add(c Comment, verdicts ...Verdict) *LabelComment {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.totalCount++
+	lc := &LabelComment{Id: act.totalCount, Type: c.Type(), Content: c.Content(), Attributes: c.Attributes()}
+	act.commentMap[lc.Id] = lc
+	act.initialQueue = append(act.initialQueue, lc)
+	return lc
+}
+
+func (act *mutexActivity) Review() (r []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	r = act.initialQueue
+	act.initialQueue = make([]*LabelComment, 0, QueueDefaultLength)
+	for _, c := range r {
+		c.Status = CommentStatusPending
+	}
+	return
+}
+
+func (act *mutexActivity) Approve(lcs []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	act.approvedQueue = append(act.approvedQueue, lcs...)
+	for _, c := range lcs {
+		c.Status = CommentStatusApproved
+	}
+}
+
+func (act *mutexActivity) Display() (r []*LabelComment) {
+	act.mutex.Lock()
+	defer act.mutex.Unlock()
+
+	r = act.approvedQueue
+	act.approvedQueue = make([]*LabelComment, 0, QueueDefaultLength)
+	for _, c := range r {
+		c.Status = CommentStatusDisplayed
+	}
+	return
+}
+
+type benchQueue interface {
+	Add(c Comment, verdicts ...Verdict) *LabelComment
+	Review() []*LabelComment
+	Approve(lcs []*LabelComment)
+	Display() []*LabelComment
+}
+
+func benchQueues(b *testing.B, f func(b *testing.B, newQueue func() benchQueue)) {
+	b.Run("mutex", func(b *testing.B) {
+		f(b, func() benchQueue { return &mutexActivity{commentMap: make(map[int]*LabelComment)} })
+	})
+	b.Run("sharded", func(b *testing.B) {
+		f(b, func() benchQueue { return &BasicActivity{} })
+	})
+}
+
+// parallel pushes, nobody polling
+func BenchmarkQueue_Push(b *testing.B) {
+	benchQueues(b, func(b *testing.B, newQueue func() benchQueue) {
+		act := newQueue()
+		c := NewTextComment("Hello", "red")
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				act.Add(c)
+			}
+		})
+	})
+}
+
+// parallel pushes while a review and a display client poll, reporting the slowest push
+func BenchmarkQueue_PushWhilePolling(b *testing.B) {
+	benchQueues(b, func(b *testing.B, newQueue func() benchQueue) {
+		act := newQueue()
+		c := NewTextComment("Hello", "red")
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				act.Approve(act.Review())
+				act.Display()
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+
+		var mutex sync.Mutex
+		var worst time.Duration
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			var w time.Duration
+			for pb.Next() {
+				start := time.Now()
+				act.Add(c)
+				w = max(w, time.Since(start))
+			}
+			mutex.Lock()
+			worst = max(worst, w)
+			mutex.Unlock()
+		})
+		b.StopTimer()
+		close(done)
+		wg.Wait()
+		b.ReportMetric(float64(worst.Microseconds()), "worst-µs")
+	})
+}
+
+// polls of an idle activity, what display clients do most of the time
+func BenchmarkQueue_EmptyPoll(b *testing.B) {
+	benchQueues(b, func(b *testing.B, newQueue func() benchQueue) {
+		act := newQueue()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			act.Review()
+			act.Display()
+		}
+	})
+}
+
+// ids of comments pushed in parallel stay unique and every comment is reviewed once
+func TestBasicActivity_ParallelPush(t *testing.T) {
+	act := &BasicActivity{}
+	act.SetRetention(RetentionPolicy{Overflow: OverflowDropOldest})
+	const pushers, pushes = 8, 500
+	var wg sync.WaitGroup
+	for p := 0; p < pushers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < pushes; i++ {
+				act.Add(NewTextComment(fmt.Sprint(p, i), "red"))
+			}
+		}(p)
+	}
+	seen := make(map[int]bool)
+	review := func() {
+		for _, c := range act.Review() {
+			assert.False(t, seen[c.Id])
+			seen[c.Id] = true
+		}
+	}
+	for len(seen) < pushers*pushes/2 {
+		review()
+	}
+	wg.Wait()
+	review()
+	assert.Equal(t, pushers*pushes, len(seen))
+	assert.Equal(t, pushers*pushes, act.Stats().TotalCount)
+	assert.Equal(t, 0, act.Stats().InitialDepth)
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	ReactionDefaultWindow = time.Second
+	ReactionMaxBursts     = 60
+	ReactionMaxCount      = 100
+)
+
+var (
+	DefaultReactions = []string{"❤️", "👏", "😂", "🎉", "👍"}
+
+	BadReactionError = &json.Error{Code: http.StatusBadRequest, Message: "reaction not allowed"}
+)
+
+// reaction counts aggregated over one window
+type ReactionBurst struct {
+	Start  time.Time
+	Counts map[string]int
+}
+
+func NewReactionBoard(allowed []string) *ReactionBoard {
+	return &ReactionBoard{
+		Window:  ReactionDefaultWindow,
+		Allowed: allowed,
+		Bursts:  make([]*ReactionBurst, 0, ReactionMaxBursts),
+		now:     time.Now,
+	}
+}
+
+// ReactionBoard aggregates emoji reactions into bursts of fixed windows,
+// bypassing comment moderation; only the latest ReactionMaxBursts bursts are kept
+type ReactionBoard struct {
+	mutex      sync.Mutex
+	Window     time.Duration
+	Allowed    []string
+	Bursts     []*ReactionBurst
+	TotalCount int
+	current    *ReactionBurst
+	now        func() time.Time
+}
+
+// check a set of reactions: non-empty and each a single character
+func ValidReactions(reactions []string) bool {
+	if len(reactions) == 0 {
+		return false
+	}
+	for _, r := range reactions {
+		if TextLength(r) != 1 || NormalizeText(r) != r {
+			return false
+		}
+	}
+	return true
+}
+
+// close the current window if it has passed
+func (b *ReactionBoard) rotate(now time.Time) {
+	if b.current == nil || now.Sub(b.current.Start) < b.Window {
+		return
+	}
+	if len(b.Bursts) == ReactionMaxBursts {
+		b.Bursts = append(b.Bursts[:0], b.Bursts[1:]...)
+	}
+	b.Bursts = append(b.Bursts, b.current)
+	b.current = nil
+}
+
+// add count reactions of an allowed emoji
+func (b *ReactionBoard) React(emoji string, count int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if count < 1 || count > ReactionMaxCount || !containsString(b.Allowed, emoji) {
+		return false
+	}
+	now := b.now()
+	b.rotate(now)
+	if b.current == nil {
+		b.current = &ReactionBurst{Start: now, Counts: make(map[string]int)}
+	}
+	b.current.Counts[emoji] += count
+	b.TotalCount += count
+	return true
+}
+
+// get bursts of closed windows since last call
+func (b *ReactionBoard) Flush() (r []*ReactionBurst) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rotate(b.now())
+	r = b.Bursts
+	b.Bursts = make([]*ReactionBurst, 0, ReactionMaxBursts)
+	return
+}
+
+// number of reactions since last reset
+func (b *ReactionBoard) Count() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.TotalCount
+}
+
+// allowed reactions
+func (b *ReactionBoard) AllowedReactions() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.Allowed
+}
+
+// replace the allowed reactions
+func (b *ReactionBoard) SetAllowed(allowed []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.Allowed = allowed
+}
+
+// reset the board
+func (b *ReactionBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.TotalCount = 0
+	b.current = nil
+	b.Bursts = make([]*ReactionBurst, 0, ReactionMaxBursts)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReactionBoard(t *testing.T) {
+	now := time.Unix(1000, 0)
+	b := NewReactionBoard([]string{"👏", "❤️"})
+	b.now = func() time.Time { return now }
+
+	assert.True(t, b.React("👏", 1))
+	assert.True(t, b.React("👏", 3))
+	assert.True(t, b.React("❤️", 1))
+	assert.False(t, b.React("💩", 1))
+	assert.False(t, b.React("👏", 0))
+	assert.False(t, b.React("👏", ReactionMaxCount+1))
+	assert.Equal(t, 5, b.TotalCount)
+
+	// the current window is still open
+	assert.Equal(t, 0, len(b.Flush()))
+
+	now = now.Add(b.Window)
+	assert.True(t, b.React("❤️", 2))
+	bs := b.Flush()
+	assert.Equal(t, 1, len(bs))
+	assert.Equal(t, map[string]int{"👏": 4, "❤️": 1}, bs[0].Counts)
+
+	now = now.Add(b.Window)
+	bs = b.Flush()
+	assert.Equal(t, 1, len(bs))
+	assert.Equal(t, map[string]int{"❤️": 2}, bs[0].Counts)
+
+	for i := 0; i < ReactionMaxBursts+5; i++ {
+		b.React("👏", 1)
+		now = now.Add(b.Window)
+	}
+	assert.Equal(t, ReactionMaxBursts, len(b.Flush()))
+
+	b.Reset()
+	assert.Equal(t, 0, b.TotalCount)
+}
+
+func TestValidReactions(t *testing.T) {
+	assert.True(t, ValidReactions(DefaultReactions))
+	assert.False(t, ValidReactions(nil))
+	assert.False(t, ValidReactions([]string{"👏👏"}))
+	assert.False(t, ValidReactions([]string{""}))
+	assert.False(t, ValidReactions([]string{"\u202e"}))
+}
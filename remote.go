@@ -0,0 +1,78 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// param of JSON-RPC calls holding the address of the client, set by RemoteAddrRPC
+const remoteAddrParam = "RemoteAddr"
+
+// set the RemoteAddr param of JSON-RPC calls to the address of the client, replacing
+// whatever the client sent, so that services can tell senders apart; params may be an
+// object or an array holding one. Batches must be split before, see BatchRPC
+func RemoteAddrRPC(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Body == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if b, ok := withRemoteAddr(body, r.RemoteAddr); ok {
+			body = b
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// the call with the address in its params, false if it has no params object
+func withRemoteAddr(call []byte, addr string) ([]byte, bool) {
+	var env map[string]json.RawMessage
+	if json.Unmarshal(call, &env) != nil {
+		return nil, false
+	}
+	var list []json.RawMessage
+	inList := json.Unmarshal(env["params"], &list) == nil && len(list) > 0
+	raw := env["params"]
+	if inList {
+		raw = list[0]
+	}
+	var params map[string]json.RawMessage
+	if json.Unmarshal(raw, &params) != nil || params == nil {
+		return nil, false
+	}
+	// params match fields ignoring case, drop any spelling of the param
+	for k := range params {
+		if strings.EqualFold(k, remoteAddrParam) {
+			delete(params, k)
+		}
+	}
+	params[remoteAddrParam], _ = json.Marshal(addr)
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, false
+	}
+	if inList {
+		list[0] = raw
+		raw, err = json.Marshal(list)
+		if err != nil {
+			return nil, false
+		}
+	}
+	env["params"] = raw
+	b, err := json.Marshal(env)
+	return b, err == nil
+}
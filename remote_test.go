@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteAddrRPC(t *testing.T) {
+	var got []byte
+	h := RemoteAddrRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ioutil.ReadAll(r.Body)
+	}))
+	call := func(body string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		var env struct {
+			Params json.RawMessage
+		}
+		assert.Nil(t, json.Unmarshal(got, &env))
+		var params map[string]interface{}
+		if json.Unmarshal(env.Params, &params) != nil {
+			var list []map[string]interface{}
+			assert.Nil(t, json.Unmarshal(env.Params, &list))
+			params = list[0]
+		}
+		return params
+	}
+
+	params := call(`{"id":1,"method":"DanmakuService.Push","params":{"Token":"t"}}`)
+	assert.Equal(t, "192.0.2.1:1234", params["RemoteAddr"])
+	assert.Equal(t, "t", params["Token"])
+	params = call(`{"id":1,"method":"DanmakuService.Push","params":[{"Token":"t","RemoteAddr":"10.0.0.1"}]}`)
+	assert.Equal(t, map[string]interface{}{"Token": "t", "RemoteAddr": "192.0.2.1:1234"}, params)
+	// the address the client claims is dropped whatever its case
+	params = call(`{"id":1,"method":"DanmakuService.Push","params":{"remoteaddr":"10.0.0.1"}}`)
+	assert.Equal(t, map[string]interface{}{"RemoteAddr": "192.0.2.1:1234"}, params)
+
+	// other bodies go through untouched
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not json"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "not json", string(got))
+}
@@ -0,0 +1,76 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"sync"
+)
+
+// senders remembered per activity at most, others have the neutral score
+const ReputationMaxSenders = 10000
+
+// comments of a sender approved and denied
+type reputation struct {
+	approved int
+	denied   int
+}
+
+func NewReputationBoard() *ReputationBoard {
+	return &ReputationBoard{senders: make(map[string]*reputation)}
+}
+
+// ReputationBoard counts the approved and denied comments of the senders of an activity
+type ReputationBoard struct {
+	mutex   sync.Mutex
+	senders map[string]*reputation
+}
+
+// count decisions on comments, those of unknown senders are left out
+func (b *ReputationBoard) Record(approved []*LabelComment, denied []*LabelComment) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, c := range approved {
+		if r := b.sender(c.Sender); r != nil {
+			r.approved++
+		}
+	}
+	for _, c := range denied {
+		if r := b.sender(c.Sender); r != nil {
+			r.denied++
+		}
+	}
+}
+
+// counts of a sender, nil if unknown or too many; must hold the mutex
+func (b *ReputationBoard) sender(s string) *reputation {
+	if s == "" {
+		return nil
+	}
+	r, ok := b.senders[s]
+	if !ok && len(b.senders) < ReputationMaxSenders {
+		r = &reputation{}
+		b.senders[s] = r
+	}
+	return r
+}
+
+// share of approved comments of a sender, smoothed so that new senders start at 0.5
+func (b *ReputationBoard) Score(s string) float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	r, ok := b.senders[s]
+	if !ok {
+		return 0.5
+	}
+	return float64(r.approved+1) / float64(r.approved+r.denied+2)
+}
+
+func (b *ReputationBoard) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.senders = make(map[string]*reputation)
+}
@@ -0,0 +1,358 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	rpcjson "github.com/antenna3mt/rpc/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+// write an engine error with its code as HTTP status
+func writeError(w http.ResponseWriter, err error) {
+	e, ok := err.(*rpcjson.Error)
+	if !ok {
+		e = &rpcjson.Error{Code: http.StatusInternalServerError, Message: err.Error()}
+	}
+	writeJSON(w, e.Code, map[string]interface{}{"error": map[string]interface{}{"code": e.Code, "message": e.Message}})
+}
+
+var BodyTooLargeError = &rpcjson.Error{Code: http.StatusRequestEntityTooLarge, Message: "body too large"}
+
+// decode a JSON request body into v, reading at most BatchMaxBytes as JSON-RPC does
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, BatchMaxBytes)).Decode(v)
+	if _, ok := err.(*http.MaxBytesError); ok {
+		return BodyTooLargeError
+	}
+	if err != nil {
+		return IllFormatError
+	}
+	return nil
+}
+
+// client id from the X-Danmaku-Client header, as returned by POST /clients
+func clientHeader(r *http.Request) string {
+	return r.Header.Get("X-Danmaku-Client")
+}
+
+// activity id from the path
+func pathId(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, NotExistError
+	}
+	return id, nil
+}
+
+func flattenComments(cs []*LabelComment) []*FlatComment {
+	r := make([]*FlatComment, 0, len(cs))
+	for _, c := range cs {
+		r = append(r, FlattenComment(c))
+	}
+	return r
+}
+
+// REST handler over the engine, authenticated by bearer token
+type restHandler struct {
+	e *Engine
+}
+
+func (h *restHandler) activities(w http.ResponseWriter, r *http.Request) {
+	acts, err := h.e.Activities(bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	flat := make([]*FlatActivity, 0, len(acts))
+	for _, act := range acts {
+		flat = append(flat, FlattenActivity(act))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"activities": flat})
+}
+
+func (h *restHandler) newActivity(w http.ResponseWriter, r *http.Request) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := readJSON(w, r, &args); err != nil {
+		writeError(w, err)
+		return
+	}
+	act, err := h.e.NewActivity(bearerToken(r), args.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"activity": FlattenActivity(act)})
+}
+
+func (h *restHandler) activity(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	acts, err := h.e.Activities(bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, act := range acts {
+		if act.Id == id {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"activity": FlattenActivity(act)})
+			return
+		}
+	}
+	writeError(w, NotExistError)
+}
+
+// update name, review switch and mode; absent fields are left alone
+func (h *restHandler) updateActivity(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	var args struct {
+		Name     *string `json:"name"`
+		ReviewOn *bool   `json:"review_on"`
+		Mode     *string `json:"mode"`
+	}
+	if err := readJSON(w, r, &args); err != nil {
+		writeError(w, err)
+		return
+	}
+	token := bearerToken(r)
+	if args.Name != nil {
+		err = h.e.RenameActivity(token, id, *args.Name)
+	}
+	if err == nil && args.ReviewOn != nil {
+		if *args.ReviewOn {
+			err = h.e.ReviewOn(token, id)
+		} else {
+			err = h.e.ReviewOff(token, id)
+		}
+	}
+	if err == nil && args.Mode != nil {
+		err = h.e.SetMode(token, id, *args.Mode)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.activity(w, r)
+}
+
+func (h *restHandler) delActivity(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err == nil {
+		err = h.e.DelActivity(bearerToken(r), id)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *restHandler) resetActivity(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err == nil {
+		err = h.e.Reset(bearerToken(r), id)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// push a comment to the activity of the token, which must match the path
+func (h *restHandler) push(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	token := bearerToken(r)
+	if act, ok := h.e.ActivityByToken(token); !ok || act.Id != id {
+		writeError(w, NotExistError)
+		return
+	}
+	var args struct {
+		Type string            `json:"type"`
+		Attr map[string]string `json:"attr"`
+	}
+	if err := readJSON(w, r, &args); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := h.e.PushFrom(NewSender(token, r.Header.Get("Idempotency-Key"), clientHeader(r), r.RemoteAddr), args.Type, args.Attr)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"comment": FlattenComment(c)})
+}
+
+func (h *restHandler) pushBatch(w http.ResponseWriter, r *http.Request) {
+	id, err := pathId(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	token := bearerToken(r)
+	if act, ok := h.e.ActivityByToken(token); !ok || act.Id != id {
+		writeError(w, NotExistError)
+		return
+	}
+	var args struct {
+		Comments []PushItem `json:"comments"`
+	}
+	if err := readJSON(w, r, &args); err != nil {
+		writeError(w, err)
+		return
+	}
+	lcs, errs, err := h.e.PushBatchFrom(NewSender(token, "", clientHeader(r), r.RemoteAddr), args.Comments)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"results": FlattenPushResults(lcs, errs)})
+}
+
+// client id for a comment token, the one in the header given back if still valid
+func (h *restHandler) newClient(w http.ResponseWriter, r *http.Request) {
+	tp, err := h.e.Login(bearerToken(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if tp != "comment" {
+		writeError(w, NotAuthorizedError)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"client": h.e.ClientId(clientHeader(r))})
+}
+
+// review with the options in the query: order, flagged_first, type (repeated) and limit
+func (h *restHandler) review(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	o := &ReviewOptions{Order: q.Get("order"), FlaggedFirst: q.Get("flagged_first") == "true", Types: q["type"]}
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil {
+			writeError(w, IllFormatError)
+			return
+		}
+		o.Limit = n
+	}
+	cs, err := h.e.ReviewWith(bearerToken(r), o)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fs := make([]*FlatComment, 0, len(cs))
+	for _, c := range cs {
+		fs = append(fs, FlattenReviewComment(c))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"comments": fs, "notice": h.e.Notice()})
+}
+
+// approve, deny or retract comments by ids
+func (h *restHandler) decide(f func(token string, ids []int) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var args struct {
+			Ids []int `json:"ids"`
+		}
+		if err := readJSON(w, r, &args); err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := f(bearerToken(r), args.Ids); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// approve and deny comments at once
+func (h *restHandler) decideBoth(w http.ResponseWriter, r *http.Request) {
+	var args struct {
+		Approve []int `json:"approve"`
+		Deny    []int `json:"deny"`
+	}
+	if err := readJSON(w, r, &args); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.e.Decide(bearerToken(r), args.Approve, args.Deny); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *restHandler) display(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	cs, err := h.e.Display(token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	rcs, err := h.e.Retractions(token)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	retracted := make([]int, 0, len(rcs))
+	for _, c := range rcs {
+		retracted = append(retracted, c.Id)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"comments": flattenComments(cs), "retracted": retracted, "notice": h.e.Notice()})
+}
+
+// resource-oriented HTTP API over the same engine as the JSON-RPC service,
+// with the OpenAPI document at /openapi.json
+func NewRESTHandler(e *Engine) http.Handler {
+	h := &restHandler{e: e}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /activities", h.activities)
+	mux.HandleFunc("POST /activities", h.newActivity)
+	mux.HandleFunc("GET /activities/{id}", h.activity)
+	mux.HandleFunc("PATCH /activities/{id}", h.updateActivity)
+	mux.HandleFunc("DELETE /activities/{id}", h.delActivity)
+	mux.HandleFunc("POST /activities/{id}/reset", h.resetActivity)
+	mux.HandleFunc("POST /activities/{id}/comments", h.push)
+	mux.HandleFunc("POST /activities/{id}/comments/batch", h.pushBatch)
+	mux.HandleFunc("POST /clients", h.newClient)
+	mux.HandleFunc("POST /review", h.review)
+	mux.HandleFunc("POST /review/approve", h.decide(e.Approve))
+	mux.HandleFunc("POST /review/deny", h.decide(e.Deny))
+	mux.HandleFunc("POST /review/decide", h.decideBoth)
+	mux.HandleFunc("POST /review/retract", h.decide(e.Retract))
+	mux.HandleFunc("POST /display", h.display)
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPIDocument)
+	})
+	return mux
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRESTHandler(t *testing.T) {
+	e := NewEngine()
+	h := NewRESTHandler(e)
+
+	do := func(method string, path string, token string, body string) (int, map[string]interface{}) {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var res map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &res)
+		return w.Code, res
+	}
+
+	code, res := do("GET", "/activities", "", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "not authorized", res["error"].(map[string]interface{})["message"])
+
+	code, res = do("POST", "/activities", e.AdminToken, `{"name":"Hello"}`)
+	assert.Equal(t, http.StatusCreated, code)
+	act := res["activity"].(map[string]interface{})
+	id := strconv.Itoa(int(act["id"].(float64)))
+	comment, review, display := act["comment_token"].(string), act["review_token"].(string), act["display_token"].(string)
+
+	code, _ = do("POST", "/activities", e.AdminToken, `{`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, res = do("PATCH", "/activities/"+id, e.AdminToken, `{"name":"World","review_on":true}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "World", res["activity"].(map[string]interface{})["name"])
+	assert.Equal(t, true, res["activity"].(map[string]interface{})["review_on"])
+
+	code, _ = do("GET", "/activities/99", e.AdminToken, "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// comment token must belong to the activity in the path
+	code, _ = do("POST", "/activities/99/comments", comment, `{"type":"text","attr":{"text":"hi","color":"red"}}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, res = do("POST", "/activities/"+id+"/comments", comment, `{"type":"text","attr":{"text":"hi","color":"red"}}`)
+	assert.Equal(t, http.StatusCreated, code)
+	cid := int(res["comment"].(map[string]interface{})["id"].(float64))
+	code, _ = do("POST", "/activities/"+id+"/comments", comment, `{"type":"text","attr":{}}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// client ids for comment tokens, given back while valid
+	code, res = do("POST", "/clients", comment, "")
+	assert.Equal(t, http.StatusCreated, code)
+	client := res["client"].(string)
+	assert.True(t, e.ValidClientId(client))
+	r := httptest.NewRequest("POST", "/clients", nil)
+	r.Header.Set("Authorization", "Bearer "+comment)
+	r.Header.Set("X-Danmaku-Client", client)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), client)
+	code, _ = do("POST", "/clients", review, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = do("POST", "/review?order=sideways", review, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, res = do("POST", "/review?type=picture&limit=5", review, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, res["comments"], 0)
+	code, res = do("POST", "/review", review, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, res["comments"], 1)
+
+	code, _ = do("POST", "/review/approve", review, `{"ids":[`+strconv.Itoa(cid)+`]}`)
+	assert.Equal(t, http.StatusNoContent, code)
+
+	code, res = do("POST", "/display", display, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, res["comments"], 1)
+	assert.Len(t, res["retracted"], 0)
+
+	code, _ = do("POST", "/review/retract", review, `{"ids":[`+strconv.Itoa(cid)+`]}`)
+	assert.Equal(t, http.StatusNoContent, code)
+	_, res = do("POST", "/display", display, "")
+	assert.Equal(t, []interface{}{float64(cid)}, res["retracted"])
+
+	// batch push and decide
+	code, res = do("POST", "/activities/"+id+"/comments/batch", comment, `{"comments":[{"type":"text","attr":{"text":"a","color":"red"}},{"type":"text","attr":{}}]}`)
+	assert.Equal(t, http.StatusOK, code)
+	results := res["results"].([]interface{})
+	bid := int(results[0].(map[string]interface{})["comment"].(map[string]interface{})["id"].(float64))
+	assert.Equal(t, float64(http.StatusBadRequest), results[1].(map[string]interface{})["error"].(map[string]interface{})["code"])
+	long := `{"comments":[` + strings.Repeat(`{"type":"text","attr":{"text":"a","color":"red"}},`, int(BatchMaxBytes)/40) + `{}]}`
+	code, res = do("POST", "/activities/"+id+"/comments/batch", comment, long)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	assert.Equal(t, "body too large", res["error"].(map[string]interface{})["message"])
+	do("POST", "/review", review, "")
+	code, _ = do("POST", "/review/decide", review, `{"deny":[`+strconv.Itoa(bid)+`]}`)
+	assert.Equal(t, http.StatusNoContent, code)
+	a, _ := e.ActivityByToken(review)
+	assert.Equal(t, 1, a.Stats().DeniedCount)
+
+	code, _ = do("POST", "/activities/"+id+"/reset", e.AdminToken, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = do("DELETE", "/activities/"+id, e.AdminToken, "")
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = do("DELETE", "/activities/"+id, e.AdminToken, "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, res = do("GET", "/openapi.json", "", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "3.0.3", res["openapi"])
+}
+
+func TestBearerToken(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, "", bearerToken(r))
+	r.Header.Set("Authorization", "bearer abc")
+	assert.Equal(t, "abc", bearerToken(r))
+	r.Header.Set("Authorization", "Basic abc")
+	assert.Equal(t, "", bearerToken(r))
+}
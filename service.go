@@ -3,7 +3,13 @@
 
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"github.com/antenna3mt/rpc/json"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 // flat format for outputting
 type FlatComment struct {
@@ -11,42 +17,306 @@ type FlatComment struct {
 	Type       string            `json:"type"`
 	Content    string            `json:"content"`
 	Attributes map[string]string `json:"attributes"`
+	Verdicts   []*FlatVerdict    `json:"verdicts,omitempty"`
 }
 
+type FlatVerdict struct {
+	Stage  string `json:"stage"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// collapsed duplicates show as a multiplier attribute
 func FlattenComment(c *LabelComment) *FlatComment {
+	attr := c.Attributes
+	if m := c.Multiplier(); m > 1 {
+		attr = make(map[string]string, len(c.Attributes)+1)
+		for k, v := range c.Attributes {
+			attr[k] = v
+		}
+		attr["multiplier"] = strconv.Itoa(m)
+	}
 	return &FlatComment{
 		Id:         c.Id,
 		Type:       c.Type,
 		Content:    c.Content,
-		Attributes: c.Attributes,
+		Attributes: attr,
+	}
+}
+
+// with the verdicts of moderation, for reviewers
+func FlattenReviewComment(c *LabelComment) *FlatComment {
+	f := FlattenComment(c)
+	for _, v := range c.Verdicts {
+		f.Verdicts = append(f.Verdicts, &FlatVerdict{Stage: v.Stage, Action: v.Action, Reason: v.Reason})
+	}
+	return f
+}
+
+type FlatError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func FlattenError(err error) *FlatError {
+	if e, ok := err.(*json.Error); ok {
+		return &FlatError{Code: e.Code, Message: e.Message}
+	}
+	return &FlatError{Code: http.StatusInternalServerError, Message: err.Error()}
+}
+
+// result of a comment of a batch push, either the comment or the error
+type FlatPushResult struct {
+	Comment *FlatComment `json:"comment,omitempty"`
+	Error   *FlatError   `json:"error,omitempty"`
+}
+
+func FlattenPushResults(lcs []*LabelComment, errs []error) []*FlatPushResult {
+	rs := make([]*FlatPushResult, len(lcs))
+	for i, c := range lcs {
+		if errs[i] != nil {
+			rs[i] = &FlatPushResult{Error: FlattenError(errs[i])}
+		} else {
+			rs[i] = &FlatPushResult{Comment: FlattenComment(c)}
+		}
 	}
+	return rs
+}
+
+type FlatReactionBurst struct {
+	Start  int64          `json:"start"`
+	Counts map[string]int `json:"counts"`
+}
+
+func FlattenReactionBurst(b *ReactionBurst) *FlatReactionBurst {
+	return &FlatReactionBurst{
+		Start:  b.Start.UnixNano() / int64(time.Millisecond),
+		Counts: b.Counts,
+	}
+}
+
+type FlatAuditEntry struct {
+	Seq        int    `json:"seq"`
+	Time       int64  `json:"time"`
+	Role       string `json:"role"`
+	ActivityId int    `json:"activity_id"`
+	Action     string `json:"action"`
+	Ids        []int  `json:"ids"`
+	Detail     string `json:"detail"`
+}
+
+func FlattenAuditEntry(en *AuditEntry) *FlatAuditEntry {
+	return &FlatAuditEntry{
+		Seq:        en.Seq,
+		Time:       en.Time.UnixNano() / int64(time.Millisecond),
+		Role:       en.Role,
+		ActivityId: en.ActivityId,
+		Action:     en.Action,
+		Ids:        en.Ids,
+		Detail:     en.Detail,
+	}
+}
+
+type FlatWebhook struct {
+	Id      int      `json:"id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Created int64    `json:"created"`
+}
+
+// the secret is left out, it is only returned when adding
+func FlattenWebhook(h *Webhook) *FlatWebhook {
+	return &FlatWebhook{
+		Id:      h.Id,
+		URL:     h.URL,
+		Events:  h.Events,
+		Created: h.Created.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+type FlatDelivery struct {
+	Id       int    `json:"id"`
+	Webhook  int    `json:"webhook"`
+	Event    string `json:"event"`
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Created  int64  `json:"created"`
+	Updated  int64  `json:"updated"`
+}
+
+func FlattenDelivery(dl *WebhookDelivery) *FlatDelivery {
+	return &FlatDelivery{
+		Id:       dl.Id,
+		Webhook:  dl.Webhook,
+		Event:    dl.Event,
+		State:    dl.State,
+		Attempts: dl.Attempts,
+		Status:   dl.Status,
+		Error:    dl.Error,
+		Created:  dl.Created.UnixNano() / int64(time.Millisecond),
+		Updated:  dl.Updated.UnixNano() / int64(time.Millisecond),
+	}
+}
+
+type FlatQuestion struct {
+	Id         int               `json:"id"`
+	Content    string            `json:"content"`
+	Attributes map[string]string `json:"attributes"`
+	Votes      int               `json:"votes"`
+	State      string            `json:"state"`
+}
+
+func FlattenQuestion(q *Question) *FlatQuestion {
+	return &FlatQuestion{
+		Id:         q.Comment.Id,
+		Content:    q.Comment.Content,
+		Attributes: q.Comment.Attributes,
+		Votes:      q.Votes,
+		State:      q.State,
+	}
+}
+
+type FlatPoll struct {
+	Id       int      `json:"id"`
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+	Open     bool     `json:"open"`
+	Tally    []int    `json:"tally"`
+}
+
+func FlattenPoll(p *Poll) *FlatPoll {
+	return &FlatPoll{
+		Id:       p.Id,
+		Question: p.Question,
+		Options:  p.Options,
+		Open:     p.Open,
+		Tally:    p.Tally,
+	}
+}
+
+type FlatTextStyle struct {
+	DefaultColor string   `json:"default_color"`
+	DefaultMode  string   `json:"default_mode"`
+	DefaultSize  string   `json:"default_size"`
+	Colors       []string `json:"colors"`
+	Modes        []string `json:"modes"`
+	Sizes        []string `json:"sizes"`
+	MinLength    int      `json:"min_length"`
+	MaxLength    int      `json:"max_length"`
+}
+
+func FlattenTextStyle(st *TextStyle) *FlatTextStyle {
+	return &FlatTextStyle{
+		DefaultColor: st.DefaultColor,
+		DefaultMode:  st.DefaultMode,
+		DefaultSize:  st.DefaultSize,
+		Colors:       st.Colors,
+		Modes:        st.Modes,
+		Sizes:        st.Sizes,
+		MinLength:    st.MinLength,
+		MaxLength:    st.MaxLength,
+	}
+}
+
+type FlatRetention struct {
+	MaxComments   int    `json:"max_comments"`
+	TTL           int    `json:"ttl"`
+	MaxQueueDepth int    `json:"max_queue_depth"`
+	Overflow      string `json:"overflow"`
+}
+
+func FlattenRetention(p RetentionPolicy) *FlatRetention {
+	return &FlatRetention{
+		MaxComments:   p.MaxComments,
+		TTL:           int(p.TTL / time.Second),
+		MaxQueueDepth: p.MaxQueueDepth,
+		Overflow:      p.Overflow,
+	}
+}
+
+type FlatDedupe struct {
+	Mode     string `json:"mode"`
+	Window   int    `json:"window"`
+	Distance int    `json:"distance"`
+}
+
+func FlattenDedupe(p DedupePolicy) *FlatDedupe {
+	return &FlatDedupe{
+		Mode:     p.Mode,
+		Window:   int(p.Window / time.Second),
+		Distance: p.Distance,
+	}
+}
+
+type FlatStage struct {
+	Name    string            `json:"name"`
+	Kind    string            `json:"kind"`
+	Options map[string]string `json:"options,omitempty"`
+}
+
+func FlattenModeration(chain ModerationChain) []*FlatStage {
+	r := make([]*FlatStage, 0, len(chain))
+	for _, stage := range chain {
+		r = append(r, &FlatStage{Name: stage.Name, Kind: stage.Kind, Options: stage.Options})
+	}
+	return r
 }
 
 type FlatActivity struct {
-	Id             int    `json:"id"`
-	Name           string `json:"name"`
-	CommentToken   string `json:"comment_token"`
-	ReviewToken    string `json:"review_token"`
-	DisplayToken   string `json:"display_token"`
-	ReviewOn       bool   `json:"review_on"`
-	TotalCount     int    `json:"total_count"`
-	ApprovedCount  int    `json:"approved_count"`
-	DeniedCount    int    `json:"denied_count"`
-	DisplayedCount int    `json:"displayed_count"`
+	Id             int            `json:"id"`
+	Name           string         `json:"name"`
+	CommentToken   string         `json:"comment_token"`
+	ReviewToken    string         `json:"review_token"`
+	DisplayToken   string         `json:"display_token"`
+	ReviewOn       bool           `json:"review_on"`
+	Mode           string         `json:"mode"`
+	Origins        []string       `json:"origins"`
+	TextStyle      *FlatTextStyle `json:"text_style"`
+	Reactions      []string       `json:"reactions"`
+	Retention      *FlatRetention `json:"retention"`
+	Dedupe         *FlatDedupe    `json:"dedupe"`
+	Moderation     []*FlatStage   `json:"moderation"`
+	TotalCount     int            `json:"total_count"`
+	ApprovedCount  int            `json:"approved_count"`
+	DeniedCount    int            `json:"denied_count"`
+	DisplayedCount int            `json:"displayed_count"`
+	RetractedCount int            `json:"retracted_count"`
+	ReactionCount  int            `json:"reaction_count"`
+	RetainedCount  int            `json:"retained_count"`
+	DroppedCount   int            `json:"dropped_count"`
+	EvictedCount   int            `json:"evicted_count"`
+	DuplicateCount int            `json:"duplicate_count"`
 }
 
 func FlattenActivity(act *Activity) *FlatActivity {
+	settings := act.Settings()
+	st := act.Stats()
 	return &FlatActivity{
 		Id:             act.Id,
-		Name:           act.Name,
+		Name:           settings.Name,
 		CommentToken:   act.CommentToken,
 		ReviewToken:    act.ReviewToken,
 		DisplayToken:   act.DisplayToken,
-		ReviewOn:       act.ReviewOn,
-		TotalCount:     act.TotalCount,
-		ApprovedCount:  act.ApprovedCount,
-		DeniedCount:    act.DeniedCount,
-		DisplayedCount: act.DisplayedCount,
+		ReviewOn:       settings.ReviewOn,
+		Mode:           settings.Mode,
+		Origins:        settings.Origins,
+		TextStyle:      FlattenTextStyle(settings.TextStyle),
+		Reactions:      act.Reactions.AllowedReactions(),
+		Retention:      FlattenRetention(act.Retention()),
+		Dedupe:         FlattenDedupe(settings.Dedupe),
+		Moderation:     FlattenModeration(settings.Moderation),
+		TotalCount:     st.TotalCount,
+		ApprovedCount:  st.ApprovedCount,
+		DeniedCount:    st.DeniedCount,
+		DisplayedCount: st.DisplayedCount,
+		RetractedCount: st.RetractedCount,
+		ReactionCount:  act.Reactions.Count(),
+		RetainedCount:  st.RetainedCount,
+		DroppedCount:   st.DroppedCount,
+		EvictedCount:   st.EvictedCount,
+		DuplicateCount: act.Dedupe.Duplicates(),
 	}
 }
 
@@ -57,16 +327,23 @@ type FlatActivityDigest struct {
 	ApprovedCount  int    `json:"approved_count"`
 	DeniedCount    int    `json:"denied_count"`
 	DisplayedCount int    `json:"displayed_count"`
+	RetractedCount int    `json:"retracted_count"`
+	ReactionCount  int    `json:"reaction_count"`
+	DroppedCount   int    `json:"dropped_count"`
 }
 
 func FlattenActivityDigest(act *Activity) *FlatActivityDigest {
+	st := act.Stats()
 	return &FlatActivityDigest{
 		Id:             act.Id,
-		Name:           act.Name,
-		TotalCount:     act.TotalCount,
-		ApprovedCount:  act.ApprovedCount,
-		DeniedCount:    act.DeniedCount,
-		DisplayedCount: act.DisplayedCount,
+		Name:           act.Settings().Name,
+		TotalCount:     st.TotalCount,
+		ApprovedCount:  st.ApprovedCount,
+		DeniedCount:    st.DeniedCount,
+		DisplayedCount: st.DisplayedCount,
+		RetractedCount: st.RetractedCount,
+		ReactionCount:  act.Reactions.Count(),
+		DroppedCount:   st.DroppedCount,
 	}
 }
 
@@ -80,17 +357,24 @@ type DanmakuService struct {
 	E *Engine
 }
 
+// login; comment tokens also get a client id, the one given back if still valid,
+// to pass on pushes, votes and upvotes
 func (s *DanmakuService) Login(ctx *Context,
 	args *struct {
-		Token string
+		Token  string
+		Client string
 	}, reply *struct {
-		Type string `json:"type"`
+		Type   string `json:"type"`
+		Client string `json:"client,omitempty"`
 	}) error {
 	tp, err := s.E.Login(args.Token)
 	if err != nil {
 		return err
 	}
 	reply.Type = tp
+	if tp == "comment" {
+		reply.Client = s.E.ClientId(args.Client)
+	}
 	return nil
 }
 
@@ -176,6 +460,234 @@ func (s *DanmakuService) ReviewOff(ctx *Context, args *struct {
 	return nil
 }
 
+// set text style; omitted fields keep their current value, MaxLength 0 lifts the limit
+func (s *DanmakuService) SetTextStyle(ctx *Context, args *struct {
+	Token        string
+	Id           int
+	DefaultColor *string
+	DefaultMode  *string
+	DefaultSize  *string
+	Colors       []string
+	Modes        []string
+	Sizes        []string
+	MinLength    *int
+	MaxLength    *int
+}, reply *struct{}) error {
+	err := s.E.UpdateTextStyle(args.Token, args.Id, func(style *TextStyle) {
+		if args.DefaultColor != nil {
+			style.DefaultColor = *args.DefaultColor
+		}
+		if args.DefaultMode != nil {
+			style.DefaultMode = *args.DefaultMode
+		}
+		if args.DefaultSize != nil {
+			style.DefaultSize = *args.DefaultSize
+		}
+		if args.Colors != nil {
+			style.Colors = args.Colors
+		}
+		if args.Modes != nil {
+			style.Modes = args.Modes
+		}
+		if args.Sizes != nil {
+			style.Sizes = args.Sizes
+		}
+		if args.MinLength != nil {
+			style.MinLength = *args.MinLength
+		}
+		if args.MaxLength != nil {
+			style.MaxLength = *args.MaxLength
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// set activity mode, danmaku or qa
+func (s *DanmakuService) SetMode(ctx *Context, args *struct {
+	Token string
+	Id    int
+	Mode  string
+}, reply *struct{}) error {
+	err := s.E.SetMode(args.Token, args.Id, args.Mode)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// set origins allowed to embed the activity
+func (s *DanmakuService) SetOrigins(ctx *Context, args *struct {
+	Token   string
+	Id      int
+	Origins []string
+}, reply *struct{}) error {
+	err := s.E.SetOrigins(args.Token, args.Id, args.Origins)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// set limits on retained comments and queue depth; ttl in seconds, zero values mean
+// as loose as the server allows
+func (s *DanmakuService) SetRetention(ctx *Context, args *struct {
+	Token         string
+	Id            int
+	MaxComments   int
+	TTL           int
+	MaxQueueDepth int
+	Overflow      string
+}, reply *struct {
+	Retention *FlatRetention `json:"retention"`
+}) error {
+	p := RetentionPolicy{
+		MaxComments:   args.MaxComments,
+		TTL:           time.Duration(args.TTL) * time.Second,
+		MaxQueueDepth: args.MaxQueueDepth,
+		Overflow:      args.Overflow,
+	}
+	if p.Overflow == "" {
+		p.Overflow = OverflowDropOldest
+	}
+	err := s.E.SetRetention(args.Token, args.Id, p)
+	if err != nil {
+		return err
+	}
+	reply.Retention = FlattenRetention(p.Clamp(DefaultRetention))
+	return nil
+}
+
+// set duplicate suppression, mode off, collapse or deny; window in seconds,
+// distance is the number of edits near-duplicates may differ by
+func (s *DanmakuService) SetDedupe(ctx *Context, args *struct {
+	Token    string
+	Id       int
+	Mode     string
+	Window   int
+	Distance int
+}, reply *struct{}) error {
+	p := DedupePolicy{
+		Mode:     args.Mode,
+		Window:   time.Duration(args.Window) * time.Second,
+		Distance: args.Distance,
+	}
+	if p.Mode == "" {
+		p.Mode = DedupeOff
+	}
+	err := s.E.SetDedupe(args.Token, args.Id, p)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// set the moderation stages pushes go through, in order; kinds: blocklist, ratelimit, dedupe
+// and any registered, each with its options
+func (s *DanmakuService) SetModeration(ctx *Context, args *struct {
+	Token  string
+	Id     int
+	Stages []StageConfig
+}, reply *struct {
+	Moderation []*FlatStage `json:"moderation"`
+}) error {
+	err := s.E.SetModeration(args.Token, args.Id, args.Stages)
+	if err != nil {
+		return err
+	}
+	act, _ := s.E.activityById(args.Id)
+	reply.Moderation = FlattenModeration(act.Settings().Moderation)
+	return nil
+}
+
+// subscribe a URL to events of an activity, of all activities for id 0; all events if none.
+// The secret signing deliveries is generated if empty and only returned here
+func (s *DanmakuService) AddWebhook(ctx *Context, args *struct {
+	Token  string
+	Id     int
+	URL    string
+	Secret string
+	Events []string
+}, reply *struct {
+	Webhook *FlatWebhook `json:"webhook"`
+	Secret  string       `json:"secret"`
+}) error {
+	h, err := s.E.AddWebhook(args.Token, args.Id, args.URL, args.Secret, args.Events)
+	if err != nil {
+		return err
+	}
+	reply.Webhook = FlattenWebhook(h)
+	reply.Secret = h.Secret
+	return nil
+}
+
+// unsubscribe
+func (s *DanmakuService) DelWebhook(ctx *Context, args *struct {
+	Token   string
+	Id      int
+	Webhook int
+}, reply *struct{}) error {
+	err := s.E.DelWebhook(args.Token, args.Id, args.Webhook)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// webhooks of an activity, of all activities for id 0, and their latest deliveries
+func (s *DanmakuService) Webhooks(ctx *Context, args *struct {
+	Token string
+	Id    int
+}, reply *struct {
+	Webhooks   []*FlatWebhook  `json:"webhooks"`
+	Deliveries []*FlatDelivery `json:"deliveries"`
+}) error {
+	hs, dls, err := s.E.Webhooks(args.Token, args.Id)
+	if err != nil {
+		return err
+	}
+	reply.Webhooks = make([]*FlatWebhook, 0, len(hs))
+	for _, h := range hs {
+		reply.Webhooks = append(reply.Webhooks, FlattenWebhook(h))
+	}
+	reply.Deliveries = make([]*FlatDelivery, 0, len(dls))
+	for i := range dls {
+		reply.Deliveries = append(reply.Deliveries, FlattenDelivery(&dls[i]))
+	}
+	return nil
+}
+
+// post a ping event to a webhook, returning the delivery once done
+func (s *DanmakuService) TestWebhook(ctx *Context, args *struct {
+	Token   string
+	Id      int
+	Webhook int
+}, reply *struct {
+	Delivery *FlatDelivery `json:"delivery"`
+}) error {
+	dl, err := s.E.TestWebhook(args.Token, args.Id, args.Webhook)
+	if err != nil {
+		return err
+	}
+	reply.Delivery = FlattenDelivery(dl)
+	return nil
+}
+
+// set allowed reactions
+func (s *DanmakuService) SetReactions(ctx *Context, args *struct {
+	Token     string
+	Id        int
+	Reactions []string
+}, reply *struct{}) error {
+	err := s.E.SetReactions(args.Token, args.Id, args.Reactions)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // get activity
 func (s *DanmakuService) GetActivityDigest(ctx *Context,
 	args *struct {
@@ -194,13 +706,16 @@ func (s *DanmakuService) GetActivityDigest(ctx *Context,
 // push a comment
 func (s *DanmakuService) Push(ctx *Context,
 	args *struct {
-		Token string
-		Type  string
-		Attr  map[string]string
+		Token      string
+		Key        string
+		Client     string
+		Type       string
+		Attr       map[string]string
+		RemoteAddr string // set by RemoteAddrRPC
 	}, reply *struct {
 		Comment *FlatComment `json:"comment"`
 	}) error {
-	c, err := s.E.Push(args.Token, args.Type, args.Attr)
+	c, err := s.E.PushFrom(NewSender(args.Token, args.Key, args.Client, args.RemoteAddr), args.Type, args.Attr)
 	if err != nil {
 		return err
 	}
@@ -208,20 +723,85 @@ func (s *DanmakuService) Push(ctx *Context,
 	return nil
 }
 
-// review
-func (s *DanmakuService) Review(ctx *Context,
+// push comments in one call, results are in the order of comments
+func (s *DanmakuService) PushBatch(ctx *Context,
+	args *struct {
+		Token      string
+		Client     string
+		Comments   []PushItem
+		RemoteAddr string // set by RemoteAddrRPC
+	}, reply *struct {
+		Results []*FlatPushResult `json:"results"`
+	}) error {
+	lcs, errs, err := s.E.PushBatchFrom(NewSender(args.Token, "", args.Client, args.RemoteAddr), args.Comments)
+	if err != nil {
+		return err
+	}
+	reply.Results = FlattenPushResults(lcs, errs)
+	return nil
+}
+
+// react with an emoji, count defaults to 1
+func (s *DanmakuService) React(ctx *Context,
+	args *struct {
+		Token string
+		Emoji string
+		Count int
+	}, reply *struct{}) error {
+	if args.Count == 0 {
+		args.Count = 1
+	}
+	err := s.E.React(args.Token, args.Emoji, args.Count)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// aggregated reaction bursts
+func (s *DanmakuService) Reactions(ctx *Context,
 	args *struct {
 		Token string
+	}, reply *struct {
+		Bursts []*FlatReactionBurst `json:"bursts"`
+	}) error {
+	bs, err := s.E.Reactions(args.Token)
+	if err != nil {
+		return err
+	}
+	reply.Bursts = make([]*FlatReactionBurst, 0, len(bs))
+	for _, b := range bs {
+		reply.Bursts = append(reply.Bursts, FlattenReactionBurst(b))
+	}
+	return nil
+}
+
+// review; order oldest (default), newest, trusted, suspect or type, flagged by moderation
+// first if FlaggedFirst, only comments of Types if any, at most Limit if positive
+func (s *DanmakuService) Review(ctx *Context,
+	args *struct {
+		Token        string
+		Order        string
+		FlaggedFirst bool
+		Types        []string
+		Limit        int
 	}, reply *struct {
 		Comments []*FlatComment `json:"comments"`
+		Notice   string         `json:"notice,omitempty"`
 	}) error {
-	cs, err := s.E.Review(args.Token)
+	cs, err := s.E.ReviewWith(args.Token, &ReviewOptions{
+		Order:        args.Order,
+		FlaggedFirst: args.FlaggedFirst,
+		Types:        args.Types,
+		Limit:        args.Limit,
+	})
 	if err != nil {
 		return err
 	}
+	reply.Notice = s.E.Notice()
 	reply.Comments = make([]*FlatComment, 0, len(cs))
 	for _, c := range cs {
-		reply.Comments = append(reply.Comments, FlattenComment(c))
+		reply.Comments = append(reply.Comments, FlattenReviewComment(c))
 	}
 	return nil
 }
@@ -252,21 +832,60 @@ func (s *DanmakuService) Deny(ctx *Context,
 	return nil
 }
 
-// display
+// approve and deny at once
+func (s *DanmakuService) Decide(ctx *Context,
+	args *struct {
+		Token   string
+		Approve []int
+		Deny    []int
+	}, reply *struct{}) error {
+	err := s.E.Decide(args.Token, args.Approve, args.Deny)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// retract
+func (s *DanmakuService) Retract(ctx *Context,
+	args *struct {
+		Token string
+		Ids   []int
+	}, reply *struct{}) error {
+	err := s.E.Retract(args.Token, args.Ids)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// display; retracted holds ids of displayed comments to be removed from screen,
+// notice is set when the server is about to restart
 func (s *DanmakuService) Display(ctx *Context,
 	args *struct {
 		Token string
 	}, reply *struct {
-		Comments []*FlatComment `json:"comments"`
+		Comments  []*FlatComment `json:"comments"`
+		Retracted []int          `json:"retracted"`
+		Notice    string         `json:"notice,omitempty"`
 	}) error {
 	cs, err := s.E.Display(args.Token)
 	if err != nil {
 		return err
 	}
+	rcs, err := s.E.Retractions(args.Token)
+	if err != nil {
+		return err
+	}
 	reply.Comments = make([]*FlatComment, 0, len(cs))
 	for _, c := range cs {
 		reply.Comments = append(reply.Comments, FlattenComment(c))
 	}
+	reply.Retracted = make([]int, 0, len(rcs))
+	for _, c := range rcs {
+		reply.Retracted = append(reply.Retracted, c.Id)
+	}
+	reply.Notice = s.E.Notice()
 	return nil
 }
 
@@ -282,3 +901,157 @@ func (s *DanmakuService) Reset(ctx *Context,
 	}
 	return nil
 }
+
+// new poll; id selects the activity for admin
+func (s *DanmakuService) NewPoll(ctx *Context,
+	args *struct {
+		Token    string
+		Id       int
+		Question string
+		Options  []string
+	}, reply *struct {
+		Poll *FlatPoll `json:"poll"`
+	}) error {
+	p, err := s.E.NewPoll(args.Token, args.Id, args.Question, args.Options)
+	if err != nil {
+		return err
+	}
+	reply.Poll = FlattenPoll(p)
+	return nil
+}
+
+// open poll
+func (s *DanmakuService) OpenPoll(ctx *Context,
+	args *struct {
+		Token  string
+		Id     int
+		PollId int
+	}, reply *struct{}) error {
+	err := s.E.SetPollOpen(args.Token, args.Id, args.PollId, true)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// close poll
+func (s *DanmakuService) ClosePoll(ctx *Context,
+	args *struct {
+		Token  string
+		Id     int
+		PollId int
+	}, reply *struct{}) error {
+	err := s.E.SetPollOpen(args.Token, args.Id, args.PollId, false)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// vote, once per client id as returned by Login
+func (s *DanmakuService) Vote(ctx *Context,
+	args *struct {
+		Token  string
+		Client string
+		PollId int
+		Option int
+	}, reply *struct{}) error {
+	err := s.E.Vote(args.Token, args.PollId, args.Client, args.Option)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// polls with tallies
+func (s *DanmakuService) Polls(ctx *Context,
+	args *struct {
+		Token string
+	}, reply *struct {
+		Polls []*FlatPoll `json:"polls"`
+	}) error {
+	ps, err := s.E.Polls(args.Token)
+	if err != nil {
+		return err
+	}
+	reply.Polls = make([]*FlatPoll, 0, len(ps))
+	for _, p := range ps {
+		reply.Polls = append(reply.Polls, FlattenPoll(p))
+	}
+	return nil
+}
+
+// upvote question, once per client id as returned by Login
+func (s *DanmakuService) Upvote(ctx *Context,
+	args *struct {
+		Token  string
+		Client string
+		Id     int
+	}, reply *struct{}) error {
+	err := s.E.Upvote(args.Token, args.Id, args.Client)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// mark question open, answering or answered
+func (s *DanmakuService) MarkQuestion(ctx *Context,
+	args *struct {
+		Token string
+		Id    int
+		State string
+	}, reply *struct{}) error {
+	err := s.E.MarkQuestion(args.Token, args.Id, args.State)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ranked questions
+func (s *DanmakuService) Questions(ctx *Context,
+	args *struct {
+		Token string
+	}, reply *struct {
+		Questions []*FlatQuestion `json:"questions"`
+	}) error {
+	qs, err := s.E.Questions(args.Token)
+	if err != nil {
+		return err
+	}
+	reply.Questions = make([]*FlatQuestion, 0, len(qs))
+	for _, q := range qs {
+		reply.Questions = append(reply.Questions, FlattenQuestion(q))
+	}
+	return nil
+}
+
+// audit log of admin and reviewer actions; entries after AfterSeq, optionally filtered
+func (s *DanmakuService) AuditLog(ctx *Context,
+	args *struct {
+		Token    string
+		Id       int
+		Role     string
+		Action   string
+		AfterSeq int
+		Limit    int
+	}, reply *struct {
+		Entries []*FlatAuditEntry `json:"entries"`
+	}) error {
+	ens, err := s.E.AuditLog(args.Token, &AuditFilter{
+		ActivityId: args.Id,
+		Role:       args.Role,
+		Action:     args.Action,
+		AfterSeq:   args.AfterSeq,
+		Limit:      args.Limit,
+	})
+	if err != nil {
+		return err
+	}
+	reply.Entries = make([]*FlatAuditEntry, 0, len(ens))
+	for _, en := range ens {
+		reply.Entries = append(reply.Entries, FlattenAuditEntry(en))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"unicode"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// raw input above this size is rejected before normalization
+	TextMaxBytes = 4096
+
+	zeroWidthNonJoiner = '\u200c'
+	zeroWidthJoiner    = '\u200d'
+	// starts the tag sequence of a subdivision flag, which the cancel tag ends
+	blackFlag = '\U0001F3F4'
+	cancelTag = '\U000E007F'
+)
+
+// characters rendered as blank but not classified as space or format characters
+var invisibleRunes = map[rune]bool{
+	'\u115f': true, // hangul choseong filler
+	'\u1160': true, // hangul jungseong filler
+	'\u2800': true, // braille pattern blank
+	'\u3164': true, // hangul filler
+	'\uffa0': true, // halfwidth hangul filler
+}
+
+// normalize text for display: NFC, drop control, invisible and bidi characters,
+// collapse runs of whitespace into a single space and trim both ends. Joiners are kept
+// between visible characters and tags only within the tag sequence of a flag
+func NormalizeText(text string) string {
+	rs := make([]rune, 0, len(text))
+	for _, r := range norm.NFC.String(text) {
+		switch {
+		case unicode.IsSpace(r):
+			r = ' '
+		case joiner(r):
+			// joins emoji sequences and shapes scripts such as Persian, kept below only
+			// when between two visible characters
+		case tag(r):
+			if n := len(rs); n == 0 || rs[n-1] != blackFlag && (!tag(rs[n-1]) || rs[n-1] == cancelTag) {
+				continue
+			}
+		case unicode.IsControl(r), unicode.Is(unicode.Cf, r), invisibleRunes[r]:
+			continue
+		}
+		rs = append(rs, r)
+	}
+
+	r := make([]rune, 0, len(rs))
+	for i, c := range rs {
+		if joiner(c) && (i == 0 || i == len(rs)-1 || !visible(rs[i-1]) || !visible(rs[i+1])) {
+			continue
+		}
+		r = append(r, c)
+	}
+	return strings.Join(strings.Fields(string(r)), " ")
+}
+
+func visible(r rune) bool {
+	return r != ' ' && !joiner(r)
+}
+
+func joiner(r rune) bool {
+	return r == zeroWidthJoiner || r == zeroWidthNonJoiner
+}
+
+// tag characters, spelling subdivision flags
+func tag(r rune) bool {
+	return r >= '\U000E0020' && r <= cancelTag
+}
+
+// length of text in user-perceived characters
+func TextLength(text string) int {
+	return uniseg.GraphemeClusterCount(text)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeText(t *testing.T) {
+	// "I want" in Persian, with a non-joiner keeping its first letters apart, and the flag of England
+	persian := "\u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645"
+	england := "\U0001F3F4\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F"
+	for in, out := range map[string]string{
+		"  hello   world ":               "hello world",
+		"hello\u3000\u00a0world":         "hello world",
+		"\u200bhello\u200b":              "hello",
+		"\u202eevil\u202c":               "evil",
+		"\u2066isolate\u2069":            "isolate",
+		"\u3164\u2800":                   "",
+		"cafe\u0301":                     "caf\u00e9",
+		"\u200dhello\u200d":              "hello",
+		"\U0001F468\u200d\U0001F469":     "\U0001F468\u200d\U0001F469",
+		"\U0001F468 \u200d \U0001F469":   "\U0001F468 \U0001F469",
+		"line\r\nbreak\ttab":             "line break tab",
+		persian:                          persian,
+		"\u200chello\u200c":              "hello",
+		england:                          england,
+		"hi\U000E0068\U000E0069":         "hi",
+		"\U0001F3F4\U000E007F\U000E0067": "\U0001F3F4\U000E007F",
+	} {
+		assert.Equal(t, out, NormalizeText(in), in)
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 5, TextLength("hello"))
+	assert.Equal(t, 2, TextLength("你好"))
+	assert.Equal(t, 1, TextLength("e\u0301"))
+	assert.Equal(t, 1, TextLength("\U0001F468\u200d\U0001F469\u200d\U0001F467"))
+	assert.Equal(t, 1, TextLength("\U0001F1E8\U0001F1F3"))
+}
@@ -0,0 +1,183 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	CertCheckInterval   = 10 * time.Second
+	SelfSignedValidity  = 365 * 24 * time.Hour
+	selfSignedSerialLen = 128
+)
+
+func NewCertReloader(certFile string, keyFile string, logger *slog.Logger) (*CertReloader, error) {
+	r := &CertReloader{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		logger:   logger,
+		now:      time.Now,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CertReloader serves a certificate from files, reloading it when the files change;
+// changes are noticed on handshakes at most once per CertCheckInterval
+type CertReloader struct {
+	mutex     sync.Mutex
+	CertFile  string
+	KeyFile   string
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+	logger    *slog.Logger
+	now       func() time.Time
+}
+
+// latest modification time of the certificate and key files
+func (r *CertReloader) filesModTime() (time.Time, error) {
+	var t time.Time
+	for _, f := range []string{r.CertFile, r.KeyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return t, err
+		}
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t, nil
+}
+
+// load the key pair, must hold the mutex once serving
+func (r *CertReloader) load() error {
+	t, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = t
+	r.lastCheck = r.now()
+	return nil
+}
+
+// certificate for tls.Config; a broken update keeps the previous certificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastCheck) < CertCheckInterval {
+		return r.cert, nil
+	}
+	r.lastCheck = now
+	if t, err := r.filesModTime(); err == nil && !t.Equal(r.modTime) {
+		if err := r.load(); err != nil {
+			r.logger.Error("reload certificate", "error", err)
+		} else {
+			r.logger.Info("certificate reloaded", "cert", r.CertFile)
+		}
+	}
+	return r.cert, nil
+}
+
+// self-signed certificate for the local host name, localhost and the addresses of all interfaces
+func NewSelfSignedCertificate() (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), selfSignedSerialLen))
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Danmaku Server"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(SelfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host, err := os.Hostname(); err == nil {
+		tmpl.DNSNames = append(tmpl.DNSNames, host)
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ipnet.IP)
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// TLS configuration from the config, nil when TLS is off; HTTP/2 is negotiated by ALPN
+func NewTLSConfig(c *Config, logger *slog.Logger) (*tls.Config, error) {
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	switch {
+	case c.TLSCert != "":
+		r, err := NewCertReloader(c.TLSCert, c.TLSKey, logger)
+		if err != nil {
+			return nil, err
+		}
+		conf.GetCertificate = r.GetCertificate
+	case c.TLSSelfSigned:
+		cert, err := NewSelfSignedCertificate()
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{*cert}
+	default:
+		return nil, nil
+	}
+	return conf, nil
+}
+
+// redirect plain HTTP requests to the HTTPS listener at httpsAddr
+func RedirectHandler(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// write a fresh self-signed key pair as PEM files
+func writeKeyPair(t *testing.T, certFile string, keyFile string) *tls.Certificate {
+	cert, err := NewSelfSignedCertificate()
+	assert.Nil(t, err)
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600))
+	return cert
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	c1 := writeKeyPair(t, certFile, keyFile)
+
+	_, err := NewCertReloader(certFile, filepath.Join(dir, "missing.pem"), slog.Default())
+	assert.Error(t, err)
+
+	r, err := NewCertReloader(certFile, keyFile, slog.New(slog.NewTextHandler(ioutil.Discard, nil)))
+	assert.Nil(t, err)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	got, _ := r.GetCertificate(nil)
+	assert.Equal(t, c1.Certificate[0], got.Certificate[0])
+
+	c2 := writeKeyPair(t, certFile, keyFile)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+
+	// not checked again before the interval
+	got, _ = r.GetCertificate(nil)
+	assert.Equal(t, c1.Certificate[0], got.Certificate[0])
+
+	now = now.Add(CertCheckInterval)
+	got, _ = r.GetCertificate(nil)
+	assert.Equal(t, c2.Certificate[0], got.Certificate[0])
+
+	// a broken update keeps serving the previous certificate
+	ioutil.WriteFile(keyFile, []byte("garbage"), 0600)
+	os.Chtimes(keyFile, later.Add(time.Minute), later.Add(time.Minute))
+	now = now.Add(CertCheckInterval)
+	got, _ = r.GetCertificate(nil)
+	assert.Equal(t, c2.Certificate[0], got.Certificate[0])
+}
+
+func TestNewSelfSignedCertificate(t *testing.T) {
+	cert, err := NewSelfSignedCertificate()
+	assert.Nil(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.Nil(t, err)
+	assert.Contains(t, leaf.DNSNames, "localhost")
+	assert.Nil(t, leaf.VerifyHostname("127.0.0.1"))
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	conf, err := NewTLSConfig(DefaultConfig(), slog.Default())
+	assert.Nil(t, err)
+	assert.Nil(t, conf)
+
+	c := DefaultConfig()
+	c.TLSSelfSigned = true
+	conf, err = NewTLSConfig(c, slog.Default())
+	assert.Nil(t, err)
+	assert.Contains(t, conf.NextProtos, "h2")
+	assert.Equal(t, 1, len(conf.Certificates))
+}
+
+func TestRedirectHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	RedirectHandler(":8443").ServeHTTP(w, httptest.NewRequest("GET", "http://venue.lan:8080/a?b=c", nil))
+	assert.Equal(t, "https://venue.lan:8443/a?b=c", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	RedirectHandler(":443").ServeHTTP(w, httptest.NewRequest("GET", "http://venue.lan/", nil))
+	assert.Equal(t, "https://venue.lan/", w.Header().Get("Location"))
+}
@@ -22,3 +22,21 @@ func IsOneOf(a interface{}, bs ...interface{}) bool {
 	}
 	return false
 }
+
+func containsString(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(is []int, i int) bool {
+	for _, j := range is {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+// Copyright 2018 Yi Jin. All rights reserved.
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// events sent to webhooks
+const (
+	EventCommentCreated   = "comment.created"
+	EventCommentApproved  = "comment.approved"
+	EventCommentDenied    = "comment.denied"
+	EventCommentDisplayed = "comment.displayed"
+	EventActivityCreated  = "activity.created"
+	EventActivityReset    = "activity.reset"
+	EventActivityDeleted  = "activity.deleted"
+	// sent by TestWebhook only
+	EventPing = "ping"
+)
+
+// states of a delivery
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	WebhookMaxCount     = 20
+	WebhookMaxHistory   = 100
+	WebhookSecretLength = 32
+	WebhookWorkers      = 4
+)
+
+var (
+	WebhookEvents = []string{
+		EventCommentCreated, EventCommentApproved, EventCommentDenied, EventCommentDisplayed,
+		EventActivityCreated, EventActivityReset, EventActivityDeleted,
+	}
+
+	WebhookTimeout     = 5 * time.Second
+	WebhookMaxAttempts = 5
+	WebhookQueueLength = 1000
+	// wait before the first retry, doubled for each next one
+	WebhookRetryBackoff = time.Second
+)
+
+// a subscription: events are posted to URL, signed with Secret; no events means all of them
+type Webhook struct {
+	Id      int
+	URL     string
+	Secret  string
+	Events  []string
+	Created time.Time
+}
+
+func (h *Webhook) subscribes(event string) bool {
+	return len(h.Events) == 0 || containsString(h.Events, event)
+}
+
+// an event posted to a webhook, or being posted
+type WebhookDelivery struct {
+	Id       int
+	Webhook  int
+	Event    string
+	State    string
+	Attempts int
+	Status   int    // HTTP status of the last attempt, 0 if none came back
+	Error    string // of the last attempt
+	Created  time.Time
+	Updated  time.Time
+}
+
+// body posted to webhooks
+type WebhookPayload struct {
+	Event    string         `json:"event"`
+	Activity int            `json:"activity"`
+	Time     int64          `json:"time"`
+	Comments []*FlatComment `json:"comments,omitempty"`
+}
+
+// signature of a delivery, sent as X-Danmaku-Signature: t=<unix time>,v1=<hex>, where v1 is
+// the HMAC-SHA256 of "<unix time>.<body>" keyed with the webhook secret
+func SignWebhook(secret string, t int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", t)
+	mac.Write(body)
+	return "t=" + strconv.FormatInt(t, 10) + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// a delivery in the queue
+type webhookJob struct {
+	board    *WebhookBoard
+	hook     *Webhook
+	delivery *WebhookDelivery
+	body     []byte
+}
+
+func NewWebhookDispatcher() *WebhookDispatcher {
+	return &WebhookDispatcher{
+		MaxAttempts: WebhookMaxAttempts,
+		Backoff:     WebhookRetryBackoff,
+		client:      &http.Client{Timeout: WebhookTimeout},
+		queue:       make(chan *webhookJob, WebhookQueueLength),
+		now:         time.Now,
+	}
+}
+
+// WebhookDispatcher posts deliveries from a queue with a few workers, started on first use;
+// failed attempts are queued again after a backoff, doubled each time, until MaxAttempts
+type WebhookDispatcher struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	client      *http.Client
+	queue       chan *webhookJob
+	once        sync.Once
+	lastId      int64
+	now         func() time.Time
+}
+
+func (d *WebhookDispatcher) enqueue(job *webhookJob) {
+	d.once.Do(func() {
+		for i := 0; i < WebhookWorkers; i++ {
+			go d.work()
+		}
+	})
+	select {
+	case d.queue <- job:
+	default:
+		job.board.update(job.delivery, func(dl *WebhookDelivery) {
+			dl.State = DeliveryFailed
+			dl.Error = "queue full"
+		})
+	}
+}
+
+func (d *WebhookDispatcher) work() {
+	for job := range d.queue {
+		if d.attempt(job) {
+			continue
+		}
+		var attempts int
+		job.board.update(job.delivery, func(dl *WebhookDelivery) { attempts = dl.Attempts })
+		if attempts >= d.MaxAttempts {
+			job.board.update(job.delivery, func(dl *WebhookDelivery) { dl.State = DeliveryFailed })
+			continue
+		}
+		time.AfterFunc(d.Backoff<<(attempts-1), func() { d.enqueue(job) })
+	}
+}
+
+// post a delivery once, recording the outcome; whether it was delivered
+func (d *WebhookDispatcher) attempt(job *webhookJob) bool {
+	t := d.now().Unix()
+	status, err := d.post(job, t)
+	ok := err == nil
+	job.board.update(job.delivery, func(dl *WebhookDelivery) {
+		dl.Attempts++
+		dl.Status = status
+		dl.Error = ""
+		if err != nil {
+			dl.Error = err.Error()
+		} else {
+			dl.State = DeliveryDelivered
+		}
+	})
+	return ok
+}
+
+func (d *WebhookDispatcher) post(job *webhookJob, t int64) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, job.hook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Danmaku-Event", job.delivery.Event)
+	req.Header.Set("X-Danmaku-Delivery", strconv.Itoa(job.delivery.Id))
+	req.Header.Set("X-Danmaku-Signature", SignWebhook(job.hook.Secret, t, job.body))
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+func NewWebhookBoard(activityId int, dispatcher *WebhookDispatcher, parent *WebhookBoard) *WebhookBoard {
+	return &WebhookBoard{
+		activityId: activityId,
+		dispatcher: dispatcher,
+		parent:     parent,
+		now:        time.Now,
+	}
+}
+
+// WebhookBoard keeps the webhooks of an activity, or of all activities for the board of
+// the engine, which is the parent of the others, and the latest deliveries to them
+type WebhookBoard struct {
+	mutex      sync.Mutex
+	activityId int
+	dispatcher *WebhookDispatcher
+	parent     *WebhookBoard
+	hooks      []*Webhook
+	hookCount  int
+	active     atomic.Int32       // len(hooks), so that events without webhooks take no lock
+	history    []*WebhookDelivery // oldest first
+	now        func() time.Time
+}
+
+// whether a URL may receive webhooks
+func ValidWebhookURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// subscribe a URL to events, all of them if none; a secret is generated if empty
+func (b *WebhookBoard) Add(u string, secret string, events []string) (*Webhook, error) {
+	if !ValidWebhookURL(u) {
+		return nil, IllFormatError
+	}
+	for _, ev := range events {
+		if !containsString(WebhookEvents, ev) {
+			return nil, IllFormatError
+		}
+	}
+	if secret == "" {
+		secret = NewAuthToken(WebhookSecretLength)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.hooks) >= WebhookMaxCount {
+		return nil, TooManyWebhooksError
+	}
+	b.hookCount++
+	h := &Webhook{Id: b.hookCount, URL: u, Secret: secret, Events: events, Created: b.now()}
+	b.hooks = append(b.hooks, h)
+	b.active.Store(int32(len(b.hooks)))
+	return h, nil
+}
+
+// unsubscribe; false if there is no such webhook
+func (b *WebhookBoard) Remove(id int) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i, h := range b.hooks {
+		if h.Id == id {
+			b.hooks = append(b.hooks[:i:i], b.hooks[i+1:]...)
+			b.active.Store(int32(len(b.hooks)))
+			return true
+		}
+	}
+	return false
+}
+
+func (b *WebhookBoard) Hooks() []*Webhook {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]*Webhook(nil), b.hooks...)
+}
+
+func (b *WebhookBoard) hook(id int) (*Webhook, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, h := range b.hooks {
+		if h.Id == id {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// copies of the latest deliveries, oldest first
+func (b *WebhookBoard) History() []WebhookDelivery {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	r := make([]WebhookDelivery, len(b.history))
+	for i, dl := range b.history {
+		r[i] = *dl
+	}
+	return r
+}
+
+// start a delivery to a webhook of the board
+func (b *WebhookBoard) deliver(h *Webhook, event string) *WebhookDelivery {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := b.now()
+	dl := &WebhookDelivery{
+		Id:      int(atomic.AddInt64(&b.dispatcher.lastId, 1)),
+		Webhook: h.Id,
+		Event:   event,
+		State:   DeliveryPending,
+		Created: now,
+		Updated: now,
+	}
+	if len(b.history) >= WebhookMaxHistory {
+		b.history[0] = nil
+		b.history = b.history[1:]
+	}
+	b.history = append(b.history, dl)
+	return dl
+}
+
+func (b *WebhookBoard) update(dl *WebhookDelivery, f func(dl *WebhookDelivery)) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	f(dl)
+	dl.Updated = b.now()
+}
+
+// webhooks of the board and its parent subscribed to an event
+func (b *WebhookBoard) subscribed(event string) (r []*webhookJob) {
+	for board := b; board != nil; board = board.parent {
+		if board.active.Load() == 0 {
+			continue
+		}
+		board.mutex.Lock()
+		for _, h := range board.hooks {
+			if h.subscribes(event) {
+				r = append(r, &webhookJob{board: board, hook: h})
+			}
+		}
+		board.mutex.Unlock()
+	}
+	return
+}
+
+// queue an event, about comments for comment events, to the webhooks subscribed to it;
+// nothing is done without any
+func (b *WebhookBoard) Emit(event string, lcs []*LabelComment) {
+	jobs := b.subscribed(event)
+	if len(jobs) == 0 {
+		return
+	}
+	p := &WebhookPayload{Event: event, Activity: b.activityId, Time: b.now().Unix()}
+	for _, c := range lcs {
+		p.Comments = append(p.Comments, FlattenComment(c))
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	for _, job := range jobs {
+		job.body = body
+		job.delivery = job.board.deliver(job.hook, event)
+		b.dispatcher.enqueue(job)
+	}
+}
+
+// post a ping to a webhook of the board once, waiting for the outcome
+func (b *WebhookBoard) Test(id int) (*WebhookDelivery, error) {
+	h, ok := b.hook(id)
+	if !ok {
+		return nil, NotExistError
+	}
+	body, err := json.Marshal(&WebhookPayload{Event: EventPing, Activity: b.activityId, Time: b.now().Unix()})
+	if err != nil {
+		return nil, err
+	}
+	job := &webhookJob{board: b, hook: h, body: body, delivery: b.deliver(h, EventPing)}
+	if !b.dispatcher.attempt(job) {
+		b.update(job.delivery, func(dl *WebhookDelivery) { dl.State = DeliveryFailed })
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	dl := *job.delivery
+	return &dl, nil
+}
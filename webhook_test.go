@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"github.com/stretchr/testify/assert"
+)
+
+// a delivery as received
+type receivedEvent struct {
+	event     string
+	signature string
+	payload   WebhookPayload
+	body      []byte
+}
+
+func webhookServer(status func(n int32) int) (*httptest.Server, chan receivedEvent) {
+	events := make(chan receivedEvent, 100)
+	var n int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		code := status(atomic.AddInt32(&n, 1))
+		w.WriteHeader(code)
+		if code != http.StatusOK {
+			return
+		}
+		ev := receivedEvent{event: r.Header.Get("X-Danmaku-Event"), signature: r.Header.Get("X-Danmaku-Signature"), body: body}
+		json.Unmarshal(body, &ev.payload)
+		events <- ev
+	}))
+	return srv, events
+}
+
+func nextEvent(t *testing.T, events chan receivedEvent) receivedEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+		return receivedEvent{}
+	}
+}
+
+// the first delivery of a board once done with
+func settledDelivery(b *WebhookBoard) WebhookDelivery {
+	for i := 0; i < 500 && b.History()[0].State == DeliveryPending; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return b.History()[0]
+}
+
+func TestSignWebhook(t *testing.T) {
+	sig := SignWebhook("secret", 1000, []byte(`{}`))
+	assert.True(t, strings.HasPrefix(sig, "t=1000,v1="))
+	assert.Equal(t, 64, len(sig)-len("t=1000,v1="))
+	assert.Equal(t, sig, SignWebhook("secret", 1000, []byte(`{}`)))
+	assert.NotEqual(t, sig, SignWebhook("secret", 1001, []byte(`{}`)))
+	assert.NotEqual(t, sig, SignWebhook("other", 1000, []byte(`{}`)))
+}
+
+func TestEngine_Webhooks(t *testing.T) {
+	srv, events := webhookServer(func(int32) int { return http.StatusOK })
+	defer srv.Close()
+	e := NewEngine()
+
+	_, err := e.AddWebhook("", 0, srv.URL, "", nil)
+	assert.Equal(t, NotAuthorizedError, err)
+	_, err = e.AddWebhook(e.AdminToken, 0, "ftp://localhost/", "", nil)
+	assert.Equal(t, IllFormatError, err)
+	_, err = e.AddWebhook(e.AdminToken, 0, srv.URL, "", []string{"comment.liked"})
+	assert.Equal(t, IllFormatError, err)
+	_, err = e.AddWebhook(e.AdminToken, 5, srv.URL, "", nil)
+	assert.Equal(t, NotExistError, err)
+
+	// webhooks of all activities hear about new ones
+	global, err := e.AddWebhook(e.AdminToken, 0, srv.URL, "", []string{EventActivityCreated})
+	assert.Nil(t, err)
+	assert.Equal(t, WebhookSecretLength, len(global.Secret))
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	ev := nextEvent(t, events)
+	assert.Equal(t, EventActivityCreated, ev.event)
+	assert.Equal(t, act.Id, ev.payload.Activity)
+	ts, _ := strconv.ParseInt(strings.TrimPrefix(strings.Split(ev.signature, ",")[0], "t="), 10, 64)
+	assert.Equal(t, SignWebhook(global.Secret, ts, ev.body), ev.signature)
+
+	h, err := e.AddWebhook(e.AdminToken, act.Id, srv.URL, "secret", []string{EventCommentCreated, EventCommentApproved, EventCommentDenied, EventCommentDisplayed})
+	assert.Nil(t, err)
+	lc, _ := e.Push(act.CommentToken, "text", map[string]string{"text": "Hi", "color": "red"})
+	ev = nextEvent(t, events)
+	assert.Equal(t, EventCommentCreated, ev.event)
+	assert.Equal(t, "Hi", ev.payload.Comments[0].Content)
+	ts, _ = strconv.ParseInt(strings.TrimPrefix(strings.Split(ev.signature, ",")[0], "t="), 10, 64)
+	assert.Equal(t, SignWebhook("secret", ts, ev.body), ev.signature)
+
+	e.Review(act.ReviewToken)
+	e.Approve(act.ReviewToken, []int{lc.Id})
+	assert.Equal(t, EventCommentApproved, nextEvent(t, events).event)
+	e.Display(act.DisplayToken)
+	ev = nextEvent(t, events)
+	assert.Equal(t, EventCommentDisplayed, ev.event)
+	assert.Equal(t, lc.Id, ev.payload.Comments[0].Id)
+
+	// events not subscribed to are not sent
+	e.Reset(e.AdminToken, act.Id)
+	lc, _ = e.Push(act.CommentToken, "text", map[string]string{"text": "Bye", "color": "red"})
+	assert.Equal(t, EventCommentCreated, nextEvent(t, events).event)
+	e.Deny(act.ReviewToken, []int{lc.Id})
+	assert.Equal(t, EventCommentDenied, nextEvent(t, events).event)
+
+	hs, dls, err := e.Webhooks(e.AdminToken, act.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Webhook{h}, hs)
+	assert.Equal(t, 5, len(dls))
+	for _, dl := range dls {
+		assert.Equal(t, h.Id, dl.Webhook)
+	}
+
+	assert.Equal(t, NotExistError, e.DelWebhook(e.AdminToken, act.Id, h.Id+1))
+	assert.Nil(t, e.DelWebhook(e.AdminToken, act.Id, h.Id))
+	hs, _, _ = e.Webhooks(e.AdminToken, act.Id)
+	assert.Equal(t, 0, len(hs))
+}
+
+func TestWebhookDispatcher_Retry(t *testing.T) {
+	// fails twice, then answers
+	srv, events := webhookServer(func(n int32) int {
+		if n <= 2 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	defer srv.Close()
+	d := NewWebhookDispatcher()
+	d.Backoff = time.Millisecond
+	b := NewWebhookBoard(1, d, nil)
+	b.Add(srv.URL, "", nil)
+
+	b.Emit(EventActivityReset, nil)
+	assert.Equal(t, EventActivityReset, nextEvent(t, events).event)
+	dl := settledDelivery(b)
+	assert.Equal(t, DeliveryDelivered, dl.State)
+	assert.Equal(t, 3, dl.Attempts)
+	assert.Equal(t, http.StatusOK, dl.Status)
+
+	// a webhook always failing is given up
+	down, _ := webhookServer(func(int32) int { return http.StatusInternalServerError })
+	defer down.Close()
+	d.MaxAttempts = 2
+	b = NewWebhookBoard(1, d, nil)
+	b.Add(down.URL, "", nil)
+	b.Emit(EventActivityReset, nil)
+	dl = settledDelivery(b)
+	assert.Equal(t, DeliveryFailed, dl.State)
+	assert.Equal(t, 2, dl.Attempts)
+	assert.Equal(t, "status 500", dl.Error)
+}
+
+func TestEngine_TestWebhook(t *testing.T) {
+	srv, events := webhookServer(func(int32) int { return http.StatusOK })
+	defer srv.Close()
+	e := NewEngine()
+	act, _ := e.NewActivity(e.AdminToken, "Hello")
+	h, _ := e.AddWebhook(e.AdminToken, act.Id, srv.URL, "", []string{EventCommentCreated})
+
+	_, err := e.TestWebhook(e.AdminToken, act.Id, h.Id+1)
+	assert.Equal(t, NotExistError, err)
+	dl, err := e.TestWebhook(e.AdminToken, act.Id, h.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, DeliveryDelivered, dl.State)
+	assert.Equal(t, http.StatusOK, dl.Status)
+	assert.Equal(t, EventPing, nextEvent(t, events).event)
+
+	for i := 1; i < WebhookMaxCount; i++ {
+		e.AddWebhook(e.AdminToken, act.Id, srv.URL, "", nil)
+	}
+	_, err = e.AddWebhook(e.AdminToken, act.Id, srv.URL, "", nil)
+	assert.Equal(t, TooManyWebhooksError, err)
+}